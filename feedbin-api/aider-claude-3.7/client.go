@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -35,6 +36,11 @@ type Client struct {
 	// User credentials for authentication
 	username string
 	password string
+	
+	// Offset between the server clock and the local clock, taken from the
+	// Date header of the most recent response
+	clockMu     sync.Mutex
+	clockOffset time.Duration
 }
 
 // NewClient returns a new Feedbin API client
@@ -56,6 +62,11 @@ func (c *Client) NewRequest(method, path string, body interface{}) (*http.Reques
 		path = "/" + path
 	}
 	
+	// Make sure path is scoped to the v2 API
+	if !strings.HasPrefix(path, "/v2/") {
+		path = "/v2" + path
+	}
+	
 	rel, err := url.Parse(path)
 	if err != nil {
 		return nil, err
@@ -100,6 +111,8 @@ func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
 	
 	defer resp.Body.Close()
 	
+	c.recordServerDate(resp)
+	
 	// Print response status for debugging
 	fmt.Printf("Response status: %s\n", resp.Status)
 	
@@ -194,7 +207,50 @@ func ParseFeedbinTime(timeStr string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("unable to parse time: %s", timeStr)
 }
 
-// FormatFeedbinTime formats a time.Time as a string in Feedbin's ISO 8601 format
+// FormatFeedbinTime formats a time.Time as a string in Feedbin's ISO 8601 format.
+// When used as a since value, t should come from the server clock (see
+// ServerNow and WatermarkStore) rather than time.Now().
 func FormatFeedbinTime(t time.Time) string {
-	return t.UTC().Format("2006-01-02T15:04:05.999999Z")
+	return t.UTC().Format("2006-01-02T15:04:05.000000Z")
+}
+
+// ParseServerDate returns the server time from the Date header of a response
+func ParseServerDate(resp *http.Response) (time.Time, bool) {
+	if resp == nil {
+		return time.Time{}, false
+	}
+	
+	date := resp.Header.Get("Date")
+	if date == "" {
+		return time.Time{}, false
+	}
+	
+	t, err := http.ParseTime(date)
+	if err != nil {
+		return time.Time{}, false
+	}
+	
+	return t.UTC(), true
+}
+
+// ServerNow returns the current time on the server clock, estimated from the
+// Date header of the most recent response. Before any response has been
+// received it returns the local time.
+func (c *Client) ServerNow() time.Time {
+	c.clockMu.Lock()
+	defer c.clockMu.Unlock()
+	
+	return time.Now().Add(c.clockOffset).UTC()
+}
+
+// recordServerDate updates the clock offset from a response's Date header
+func (c *Client) recordServerDate(resp *http.Response) {
+	serverTime, ok := ParseServerDate(resp)
+	if !ok {
+		return
+	}
+	
+	c.clockMu.Lock()
+	c.clockOffset = serverTime.Sub(time.Now())
+	c.clockMu.Unlock()
 }
@@ -2,7 +2,6 @@ package feedbin
 
 import (
 	"net/http"
-	"net/http/httptest"
 	"testing"
 	"time"
 )
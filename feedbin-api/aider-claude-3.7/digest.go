@@ -0,0 +1,529 @@
+package feedbin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UntaggedGroup is the group name used for feeds without tags
+const UntaggedGroup = "Untagged"
+
+// DigestOptions controls which entries go into a digest
+type DigestOptions struct {
+	// Title is used as the heading and email subject
+	Title string
+
+	// Tags limits the digest to feeds with any of these tags. When empty all
+	// unread entries are included.
+	Tags []string
+
+	// Since limits the digest to entries created after this time. When zero
+	// and StatePath is set, the watermark saved by the last run is used.
+	Since time.Time
+
+	// StatePath is the file the digest watermark is persisted to by FinishDigest
+	StatePath string
+
+	// SummaryLength is the maximum number of characters per summary
+	SummaryLength int
+
+	// MaxEntries caps the number of entries in the digest, newest first
+	MaxEntries int
+
+	// Icons embeds feed favicons in the HTML rendering
+	Icons bool
+}
+
+// Digest is a set of entries grouped by tag and feed
+type Digest struct {
+	Title       string
+	GeneratedAt time.Time
+	Since       time.Time
+
+	// Until is the newest entry creation time in the digest, used as the
+	// watermark for the next run
+	Until  time.Time
+	Groups []*DigestGroup
+
+	statePath string
+}
+
+// DigestGroup holds the feeds of one tag
+type DigestGroup struct {
+	Tag   string
+	Feeds []*DigestFeed
+}
+
+// DigestFeed holds the entries of one feed
+type DigestFeed struct {
+	FeedID  int64
+	Title   string
+	SiteURL string
+	Icon    *DigestIcon
+	Entries []DigestEntry
+}
+
+// DigestEntry is an entry with a trimmed plain-text summary
+type DigestEntry struct {
+	Entry
+	Excerpt string
+}
+
+// DigestIcon is a favicon embedded in the HTML email
+type DigestIcon struct {
+	ContentID   string
+	ContentType string
+	Data        []byte
+}
+
+// digestState is the persisted digest watermark
+type digestState struct {
+	Since time.Time `json:"since"`
+}
+
+// BuildDigest gathers unread entries created since the last run and groups
+// them by tag and feed
+func (c *Client) BuildDigest(opts DigestOptions) (*Digest, error) {
+	if opts.Title == "" {
+		opts.Title = "Feedbin digest"
+	}
+	if opts.SummaryLength <= 0 {
+		opts.SummaryLength = 280
+	}
+
+	since := opts.Since
+	if since.IsZero() && opts.StatePath != "" {
+		state, err := readDigestState(opts.StatePath)
+		if err != nil {
+			return nil, err
+		}
+		since = state.Since
+	}
+
+	subscriptions, err := c.GetSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	taggings, err := c.GetTaggings()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := c.getUnreadDigestEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &Digest{
+		Title:       opts.Title,
+		GeneratedAt: time.Now().UTC(),
+		Since:       since,
+		Until:       since,
+		statePath:   opts.StatePath,
+	}
+
+	entries, digest.Until = filterDigestEntries(entries, since, digestFeedIDs(taggings, opts.Tags), opts.MaxEntries)
+
+	digest.Groups = groupDigestEntries(entries, subscriptions, taggings, opts)
+
+	if opts.Icons {
+		c.attachDigestIcons(digest)
+	}
+
+	return digest, nil
+}
+
+// EntryIDs returns the IDs of every entry in the digest
+func (d *Digest) EntryIDs() []int64 {
+	var ids []int64
+	for _, group := range d.Groups {
+		for _, feed := range group.Feeds {
+			for _, entry := range feed.Entries {
+				ids = append(ids, entry.ID)
+			}
+		}
+	}
+
+	return ids
+}
+
+// Empty reports whether the digest has no entries
+func (d *Digest) Empty() bool {
+	return len(d.Groups) == 0
+}
+
+// FinishDigest persists the digest watermark and optionally marks the
+// digested entries as read. Call it once the digest has been delivered.
+func (c *Client) FinishDigest(d *Digest, markRead bool) error {
+	if markRead {
+		for _, ids := range batchIDs(d.EntryIDs(), MaxMutationIDs) {
+			if err := c.MarkEntriesAsRead(ids); err != nil {
+				return fmt.Errorf("error marking digest entries as read: %v", err)
+			}
+		}
+	}
+
+	if d.statePath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(digestState{Since: d.Until}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(d.statePath, data)
+}
+
+// Markdown renders the digest as Markdown
+func (d *Digest) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", d.Title)
+	fmt.Fprintf(&b, "_%d entries, %s_\n", len(d.EntryIDs()), d.GeneratedAt.Format("Monday, January 2, 2006"))
+
+	for _, group := range d.Groups {
+		fmt.Fprintf(&b, "\n## %s\n", markdownEscape(group.Tag))
+
+		for _, feed := range group.Feeds {
+			fmt.Fprintf(&b, "\n### %s\n\n", markdownEscape(feed.Title))
+
+			for _, entry := range feed.Entries {
+				title := entry.Title
+				if title == "" {
+					title = "Untitled"
+				}
+
+				fmt.Fprintf(&b, "- [%s](%s)", markdownEscape(title), entry.URL)
+				if entry.Author != "" {
+					fmt.Fprintf(&b, " — %s", markdownEscape(entry.Author))
+				}
+				b.WriteString("\n")
+
+				if entry.Excerpt != "" {
+					fmt.Fprintf(&b, "  %s\n", markdownEscape(entry.Excerpt))
+				}
+			}
+		}
+	}
+
+	return b.String()
+}
+
+var digestTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: -apple-system, Helvetica, Arial, sans-serif; max-width: 640px; margin: 0 auto; color: #222;">
+<h1 style="font-size: 22px;">{{.Title}}</h1>
+{{range .Groups}}<h2 style="font-size: 18px; border-bottom: 1px solid #ddd;">{{.Tag}}</h2>
+{{range .Feeds}}<h3 style="font-size: 15px;">{{if .Icon}}<img src="cid:{{.Icon.ContentID}}" width="16" height="16" alt="" style="vertical-align: middle; margin-right: 6px;">{{end}}{{.Title}}</h3>
+<ul style="padding-left: 20px;">
+{{range .Entries}}<li style="margin-bottom: 10px;"><a href="{{.URL}}">{{if .Title}}{{.Title}}{{else}}Untitled{{end}}</a>{{if .Author}} <span style="color: #777;">— {{.Author}}</span>{{end}}{{if .Excerpt}}<br><span style="color: #444;">{{.Excerpt}}</span>{{end}}</li>
+{{end}}</ul>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// HTML renders the digest as an HTML document. Feed icons are referenced
+// by content ID and are attached by EmailMessage.
+func (d *Digest) HTML() (string, error) {
+	var buf bytes.Buffer
+	if err := digestTemplate.Execute(&buf, d); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// getUnreadDigestEntries retrieves every unread entry. The unread IDs come
+// from GetUnreadEntries and the entries themselves from GetEntriesByIDs, so
+// the since filter is applied locally by filterDigestEntries.
+func (c *Client) getUnreadDigestEntries() ([]Entry, error) {
+	ids, err := c.GetUnreadEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return c.GetEntriesByIDs(ids)
+}
+
+// digestFeedIDs returns the feeds tagged with any of tags, or nil when tags
+// is empty and every feed is included
+func digestFeedIDs(taggings []Tagging, tags []string) map[int64]bool {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	feedIDs := make(map[int64]bool)
+	for _, tagging := range taggings {
+		if containsString(tags, tagging.Name) {
+			feedIDs[tagging.FeedID] = true
+		}
+	}
+
+	return feedIDs
+}
+
+// filterDigestEntries keeps entries created after since from the given
+// feeds (all feeds when feedIDs is nil), up to max, newest first. The cap
+// keeps the oldest entries by created_at so that the returned watermark only
+// covers entries in the digest and the rest roll over to the next run.
+// Entries are filtered before the cap so that max applies to the entries in
+// the digest.
+func filterDigestEntries(entries []Entry, since time.Time, feedIDs map[int64]bool, max int) ([]Entry, time.Time) {
+	var filtered []Entry
+	for _, entry := range entries {
+		if !since.IsZero() && !entry.CreatedAt.After(since) {
+			continue
+		}
+		if feedIDs != nil && !feedIDs[entry.FeedID] {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].CreatedAt.Before(filtered[j].CreatedAt)
+	})
+
+	until := since
+	if len(filtered) > 0 {
+		until = filtered[len(filtered)-1].CreatedAt
+	}
+
+	if max > 0 && len(filtered) > max {
+		// Entries sharing the created_at of the oldest dropped entry roll
+		// over with it, since the next run only sees entries after until
+		dropped := filtered[max].CreatedAt
+		cut := max
+		for cut > 0 && !filtered[cut-1].CreatedAt.Before(dropped) {
+			cut--
+		}
+
+		if cut > 0 {
+			until = filtered[cut-1].CreatedAt
+		} else {
+			cut = max
+			until = dropped.Add(-time.Nanosecond)
+		}
+		filtered = filtered[:cut]
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Published.After(filtered[j].Published)
+	})
+
+	return filtered, until
+}
+
+// groupDigestEntries arranges entries into tag groups and feeds. Feeds with
+// several tags are listed under the first matching tag only.
+func groupDigestEntries(entries []Entry, subscriptions []Subscription, taggings []Tagging, opts DigestOptions) []*DigestGroup {
+	subscriptionsByFeed := make(map[int64]Subscription)
+	for _, subscription := range subscriptions {
+		subscriptionsByFeed[subscription.FeedID] = subscription
+	}
+
+	tagsByFeed := make(map[int64][]string)
+	for _, tagging := range taggings {
+		if len(opts.Tags) > 0 && !containsString(opts.Tags, tagging.Name) {
+			continue
+		}
+		tagsByFeed[tagging.FeedID] = append(tagsByFeed[tagging.FeedID], tagging.Name)
+	}
+
+	groups := make(map[string]*DigestGroup)
+	feeds := make(map[int64]*DigestFeed)
+
+	for _, entry := range entries {
+		tags := tagsByFeed[entry.FeedID]
+		if len(opts.Tags) > 0 && len(tags) == 0 {
+			continue
+		}
+
+		tag := UntaggedGroup
+		if len(tags) > 0 {
+			sort.Strings(tags)
+			tag = tags[0]
+		}
+
+		feed, ok := feeds[entry.FeedID]
+		if !ok {
+			subscription := subscriptionsByFeed[entry.FeedID]
+			feed = &DigestFeed{
+				FeedID:  entry.FeedID,
+				Title:   subscription.Title,
+				SiteURL: subscription.SiteURL,
+			}
+			if feed.Title == "" {
+				feed.Title = fmt.Sprintf("Feed %d", entry.FeedID)
+			}
+			feeds[entry.FeedID] = feed
+
+			group, ok := groups[tag]
+			if !ok {
+				group = &DigestGroup{Tag: tag}
+				groups[tag] = group
+			}
+			group.Feeds = append(group.Feeds, feed)
+		}
+
+		feed.Entries = append(feed.Entries, DigestEntry{
+			Entry:   entry,
+			Excerpt: excerpt(entry, opts.SummaryLength),
+		})
+	}
+
+	var result []*DigestGroup
+	for _, group := range groups {
+		sort.Slice(group.Feeds, func(i, j int) bool {
+			return strings.ToLower(group.Feeds[i].Title) < strings.ToLower(group.Feeds[j].Title)
+		})
+		result = append(result, group)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Tag == UntaggedGroup) != (result[j].Tag == UntaggedGroup) {
+			return result[j].Tag == UntaggedGroup
+		}
+		return strings.ToLower(result[i].Tag) < strings.ToLower(result[j].Tag)
+	})
+
+	return result
+}
+
+// attachDigestIcons downloads the favicon of every feed in the digest.
+// Icons that can't be downloaded are skipped.
+func (c *Client) attachDigestIcons(d *Digest) {
+	icons, err := c.GetIcons()
+	if err != nil {
+		return
+	}
+
+	iconsByHost := make(map[string]string)
+	for _, icon := range icons {
+		iconsByHost[strings.TrimPrefix(icon.Host, "www.")] = icon.URL
+	}
+
+	for _, group := range d.Groups {
+		for _, feed := range group.Feeds {
+			site, err := url.Parse(feed.SiteURL)
+			if err != nil {
+				continue
+			}
+
+			iconURL := iconsByHost[strings.TrimPrefix(site.Hostname(), "www.")]
+			if iconURL == "" {
+				continue
+			}
+
+			data, contentType, err := c.download(iconURL, 64*1024)
+			if err != nil {
+				continue
+			}
+
+			feed.Icon = &DigestIcon{
+				ContentID:   fmt.Sprintf("icon-%d@feedbin", feed.FeedID),
+				ContentType: contentType,
+				Data:        data,
+			}
+		}
+	}
+}
+
+// download fetches a public resource without API credentials, up to limit bytes
+func (c *Client) download(resourceURL string, limit int64) ([]byte, string, error) {
+	resp, err := c.client.Get(resourceURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("error downloading %s: %s", resourceURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > limit {
+		return nil, "", fmt.Errorf("%s is larger than %d bytes", resourceURL, limit)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	return data, contentType, nil
+}
+
+// readDigestState reads the digest watermark, returning an empty state if
+// the file doesn't exist yet
+func readDigestState(path string) (digestState, error) {
+	var state digestState
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("error reading digest state %s: %v", path, err)
+	}
+
+	return state, nil
+}
+
+// excerpt returns the entry summary, or its content as text, trimmed to
+// length characters at a word boundary
+func excerpt(entry Entry, length int) string {
+	text := plainText(entry.Summary)
+	if text == "" {
+		text = plainText(entry.Content)
+	}
+
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	cut := string(runes[:length])
+	if i := strings.LastIndex(cut, " "); i > length/2 {
+		cut = cut[:i]
+	}
+
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
+
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"[", `\[`,
+	"]", `\]`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+)
+
+// markdownEscape escapes characters with special meaning in Markdown text
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
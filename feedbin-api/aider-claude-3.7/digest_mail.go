@@ -0,0 +1,234 @@
+package feedbin
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTPConfig describes how digests are delivered
+type SMTPConfig struct {
+	// Addr is the host:port of the SMTP server
+	Addr string
+
+	// Username and Password enable PLAIN authentication when set
+	Username string
+	Password string
+
+	// StartTLS requires the connection to be upgraded before authenticating
+	StartTLS bool
+
+	// TLSConfig is used for STARTTLS, defaults to verifying the server host
+	TLSConfig *tls.Config
+
+	From string
+	To   []string
+
+	// Timeout bounds connecting to the server and the whole SMTP exchange,
+	// so a stalled server cannot hang delivery. Defaults to 30 seconds.
+	Timeout time.Duration
+}
+
+// EmailMessage renders the digest as a MIME message with a Markdown text
+// part and an HTML part with inline favicons
+func (d *Digest) EmailMessage(from string, to []string) ([]byte, error) {
+	htmlBody, err := d.HTML()
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	alternative := multipart.NewWriter(&msg)
+
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", d.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", d.GeneratedAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", alternative.Boundary())
+
+	if err := writeQuotedPrintable(alternative, "text/plain; charset=utf-8", d.Markdown()); err != nil {
+		return nil, err
+	}
+
+	var related bytes.Buffer
+	relatedWriter := multipart.NewWriter(&related)
+
+	if err := writeQuotedPrintable(relatedWriter, "text/html; charset=utf-8", htmlBody); err != nil {
+		return nil, err
+	}
+
+	for _, group := range d.Groups {
+		for _, feed := range group.Feeds {
+			if feed.Icon == nil {
+				continue
+			}
+
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Type", feed.Icon.ContentType)
+			header.Set("Content-Transfer-Encoding", "base64")
+			header.Set("Content-ID", "<"+feed.Icon.ContentID+">")
+			header.Set("Content-Disposition", "inline")
+
+			part, err := relatedWriter.CreatePart(header)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := writeBase64Lines(part, feed.Icon.Data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := relatedWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", fmt.Sprintf("multipart/related; type=\"text/html\"; boundary=%q", relatedWriter.Boundary()))
+
+	part, err := alternative.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := part.Write(related.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+
+	return msg.Bytes(), nil
+}
+
+// SendDigest delivers the digest by email
+func SendDigest(cfg SMTPConfig, d *Digest) error {
+	if cfg.Addr == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return fmt.Errorf("SMTP address, sender and recipients are required")
+	}
+
+	msg, err := d.EmailMessage(cfg.From, cfg.To)
+	if err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %s: %v", cfg.Addr, err)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	conn, err := net.DialTimeout("tcp", cfg.Addr, timeout)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if cfg.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server %s does not support STARTTLS", cfg.Addr)
+		}
+
+		tlsConfig := cfg.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: host}
+		}
+
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %v", err)
+		}
+	}
+
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %v", err)
+		}
+	}
+
+	if err := client.Mail(cfg.From); err != nil {
+		return err
+	}
+
+	for _, recipient := range cfg.To {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("recipient %s rejected: %v", recipient, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// writeQuotedPrintable adds a quoted-printable text part
+func writeQuotedPrintable(w *multipart.Writer, contentType, body string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
+
+// writeBase64Lines writes data as base64 wrapped at 76 characters
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+
+	_, err := fmt.Fprintf(w, "%s\r\n", encoded)
+	return err
+}
@@ -0,0 +1,327 @@
+package feedbin
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStandIn is a minimal SMTP server that accepts one message
+type smtpStandIn struct {
+	listener net.Listener
+	auth     string
+	rcpt     []string
+	data     chan string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	s := &smtpStandIn{listener: listener, data: make(chan string, 1)}
+	go s.serve()
+
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch command {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = line
+			reply("235 Authentication successful")
+		case "MAIL":
+			reply("250 OK")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.data <- data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestBuildAndSendDigest(t *testing.T) {
+	now := time.Now().UTC()
+	var read []int64
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/unread_entries.json" && r.Method == http.MethodDelete:
+			var req UnreadEntryRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			read = append(read, req.UnreadEntries...)
+		case r.URL.Path == "/v2/unread_entries.json":
+			json.NewEncoder(w).Encode([]int64{1, 2, 3})
+		case r.URL.Path == "/v2/entries.json":
+			if r.URL.Query().Get("ids") != "1,2,3" {
+				t.Errorf("Expected the unread entries to be requested by ID, got %s", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode([]Entry{
+				{ID: 1, FeedID: 10, Title: "Go 1.22 is released", URL: "https://go.dev/blog/go1.22", Summary: strings.Repeat("Generics and more. ", 30), Published: now, CreatedAt: now},
+				{ID: 2, FeedID: 11, Title: "Morning news", URL: "https://news.example/1", Content: "<p>Headlines</p>", Published: now.Add(-time.Hour), CreatedAt: now.Add(-time.Hour)},
+				{ID: 3, FeedID: 10, Title: "Old post", URL: "https://go.dev/blog/old", Published: now.Add(-48 * time.Hour), CreatedAt: now.Add(-48 * time.Hour)},
+			})
+		case r.URL.Path == "/v2/subscriptions.json":
+			json.NewEncoder(w).Encode([]Subscription{
+				{ID: 1, FeedID: 10, Title: "The Go Blog", SiteURL: "https://go.dev"},
+				{ID: 2, FeedID: 11, Title: "News", SiteURL: "https://news.example"},
+			})
+		case r.URL.Path == "/v2/taggings.json":
+			json.NewEncoder(w).Encode([]Tagging{{ID: 1, FeedID: 10, Name: "Tech"}})
+		case r.URL.Path == "/v2/icons.json":
+			json.NewEncoder(w).Encode([]Icon{{Host: "go.dev", URL: server.URL + "/icon.png"}})
+		case r.URL.Path == "/icon.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG fake icon"))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pass")
+	client.baseURL, _ = url.Parse(server.URL)
+
+	statePath := filepath.Join(t.TempDir(), "digest-state.json")
+	digest, err := client.BuildDigest(DigestOptions{
+		Title:         "Daily digest",
+		Since:         now.Add(-24 * time.Hour),
+		StatePath:     statePath,
+		SummaryLength: 60,
+		Icons:         true,
+	})
+	if err != nil {
+		t.Fatalf("BuildDigest returned error: %v", err)
+	}
+
+	if ids := digest.EntryIDs(); len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("Expected entries 1 and 2 in digest, got %v", ids)
+	}
+
+	if len(digest.Groups) != 2 || digest.Groups[0].Tag != "Tech" || digest.Groups[1].Tag != UntaggedGroup {
+		t.Fatalf("Expected Tech and Untagged groups, got %+v", digest.Groups)
+	}
+
+	goBlog := digest.Groups[0].Feeds[0]
+	if goBlog.Icon == nil || goBlog.Icon.ContentType != "image/png" {
+		t.Error("Expected The Go Blog icon to be attached")
+	}
+
+	if excerpt := goBlog.Entries[0].Excerpt; len([]rune(excerpt)) > 61 || !strings.HasSuffix(excerpt, "…") {
+		t.Errorf("Expected trimmed excerpt, got %q", excerpt)
+	}
+
+	markdown := digest.Markdown()
+	if !strings.Contains(markdown, "## Tech") || !strings.Contains(markdown, "- [Go 1.22 is released](https://go.dev/blog/go1.22)") {
+		t.Errorf("Unexpected markdown:\n%s", markdown)
+	}
+
+	smtpServer := newSMTPStandIn(t)
+	defer smtpServer.listener.Close()
+
+	err = SendDigest(SMTPConfig{
+		Addr:     smtpServer.listener.Addr().String(),
+		Username: "digest",
+		Password: "secret",
+		From:     "digest@example.com",
+		To:       []string{"reader@example.com"},
+	}, digest)
+	if err != nil {
+		t.Fatalf("SendDigest returned error: %v", err)
+	}
+
+	if !strings.HasPrefix(smtpServer.auth, "AUTH PLAIN") {
+		t.Errorf("Expected PLAIN authentication, got %q", smtpServer.auth)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-smtpServer.data))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %s", mediaType)
+	}
+
+	var types []string
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err != nil {
+			break
+		}
+		partType, partParams, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		types = append(types, partType)
+
+		if partType == "multipart/related" {
+			related := multipart.NewReader(part, partParams["boundary"])
+			for {
+				inner, err := related.NextPart()
+				if err != nil {
+					break
+				}
+				types = append(types, inner.Header.Get("Content-Type"))
+				if id := inner.Header.Get("Content-ID"); id != "" && id != "<icon-10@feedbin>" {
+					t.Errorf("Unexpected Content-ID %s", id)
+				}
+			}
+		}
+	}
+
+	expected := "text/plain,multipart/related,text/html; charset=utf-8,image/png"
+	if strings.Join(types, ",") != expected {
+		t.Errorf("Expected parts %s, got %s", expected, strings.Join(types, ","))
+	}
+
+	if err := client.FinishDigest(digest, true); err != nil {
+		t.Fatalf("FinishDigest returned error: %v", err)
+	}
+
+	if len(read) != 2 {
+		t.Errorf("Expected 2 entries to be marked read, got %v", read)
+	}
+
+	state, err := readDigestState(statePath)
+	if err != nil {
+		t.Fatalf("readDigestState returned error: %v", err)
+	}
+
+	if !state.Since.Equal(digest.Until) {
+		t.Errorf("Expected watermark %v, got %v", digest.Until, state.Since)
+	}
+}
+
+func TestFilterDigestEntriesAppliesTagsBeforeCap(t *testing.T) {
+	now := time.Now().UTC()
+	var entries []Entry
+	for i := 1; i <= 10; i++ {
+		feedID := int64(11)
+		if i > 7 {
+			feedID = 10
+		}
+		entries = append(entries, Entry{ID: int64(i), FeedID: feedID, Published: now.Add(-time.Duration(i) * time.Minute), CreatedAt: now})
+	}
+
+	feedIDs := digestFeedIDs([]Tagging{{FeedID: 10, Name: "Tech"}, {FeedID: 11, Name: "News"}}, []string{"Tech"})
+	filtered, _ := filterDigestEntries(entries, now.Add(-time.Hour), feedIDs, 2)
+
+	if len(filtered) != 2 || filtered[0].ID != 8 || filtered[1].ID != 9 {
+		t.Errorf("Expected 2 Tech entries, got %v", filtered)
+	}
+}
+
+func TestFilterDigestEntriesRollsCappedEntriesOver(t *testing.T) {
+	now := time.Now().UTC()
+	since := now.Add(-time.Hour)
+	entries := []Entry{
+		{ID: 1, Published: now, CreatedAt: now.Add(-10 * time.Minute)},
+		{ID: 2, Published: now.Add(-2 * time.Hour), CreatedAt: now.Add(-50 * time.Minute)},
+		{ID: 3, Published: now.Add(-time.Hour), CreatedAt: now.Add(-30 * time.Minute)},
+		{ID: 4, Published: now.Add(-3 * time.Hour), CreatedAt: now.Add(-30 * time.Minute)},
+	}
+
+	filtered, until := filterDigestEntries(entries, since, nil, 2)
+	if len(filtered) != 1 || filtered[0].ID != 2 {
+		t.Fatalf("Expected only the oldest entry by created_at, got %v", filtered)
+	}
+
+	if !until.Equal(now.Add(-50 * time.Minute)) {
+		t.Errorf("Expected watermark at the last included entry, got %v", until)
+	}
+
+	next, _ := filterDigestEntries(entries, until, nil, 2)
+	if len(next) != 2 || next[0].ID != 3 || next[1].ID != 4 {
+		t.Errorf("Expected the capped entries in the next run, got %v", next)
+	}
+
+	_, until = filterDigestEntries(entries, since, nil, 0)
+	if !until.Equal(now.Add(-10 * time.Minute)) {
+		t.Errorf("Expected watermark at the newest entry without a cap, got %v", until)
+	}
+}
+
+func TestSendDigestTimesOutOnStalledServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	// Accept the connection but never send the greeting
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	start := time.Now()
+	err = SendDigest(SMTPConfig{
+		Addr:    listener.Addr().String(),
+		From:    "digest@example.com",
+		To:      []string{"reader@example.com"},
+		Timeout: 100 * time.Millisecond,
+	}, &Digest{Title: "Daily digest"})
+
+	if err == nil {
+		t.Fatal("Expected SendDigest to fail on a stalled server")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected SendDigest to give up after the timeout, took %v", elapsed)
+	}
+}
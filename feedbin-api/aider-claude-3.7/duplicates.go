@@ -0,0 +1,287 @@
+package feedbin
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// trackingParams are query parameters that identify a campaign or referrer
+// rather than the content, and are removed when canonicalising URLs
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"ref":     true,
+	"ref_src": true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+}
+
+// DuplicateOptions controls how near-duplicate entries are detected
+type DuplicateOptions struct {
+	// TitleDistance is the maximum number of differing SimHash bits for two
+	// titles to be considered the same story
+	TitleDistance int
+
+	// ContentDistance is the maximum number of differing SimHash bits for two
+	// contents to be considered the same story
+	ContentDistance int
+
+	// MinTitleWords skips title matching for titles shorter than this, since
+	// short titles like "Update" collide across unrelated stories
+	MinTitleWords int
+
+	// MinContentWords skips content matching for contents shorter than this
+	MinContentWords int
+}
+
+// DefaultDuplicateOptions returns the options used when none are given
+func DefaultDuplicateOptions() DuplicateOptions {
+	return DuplicateOptions{
+		TitleDistance:   3,
+		ContentDistance: 6,
+		MinTitleWords:   4,
+		MinContentWords: 30,
+	}
+}
+
+// DuplicateCluster is a group of entries that carry the same story
+type DuplicateCluster struct {
+	// Representative is the entry to keep: the one with the longest content,
+	// then the earliest published, then the lowest ID
+	Representative Entry
+
+	// Duplicates are the other entries in the cluster
+	Duplicates []Entry
+}
+
+// DuplicateIDs returns the IDs of the duplicates in the cluster
+func (c DuplicateCluster) DuplicateIDs() []int64 {
+	ids := make([]int64, len(c.Duplicates))
+	for i, entry := range c.Duplicates {
+		ids[i] = entry.ID
+	}
+
+	return ids
+}
+
+// FindDuplicates groups entries that share a canonical URL or have
+// near-identical titles or content. Only clusters with at least two entries
+// are returned, ordered by representative ID.
+func FindDuplicates(entries []Entry, opts *DuplicateOptions) []DuplicateCluster {
+	options := DefaultDuplicateOptions()
+	if opts != nil {
+		options = *opts
+	}
+
+	type fingerprint struct {
+		title        uint64
+		content      uint64
+		hasTitle     bool
+		hasContent   bool
+		contentWords int
+	}
+
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	union := func(i, j int) {
+		ri, rj := find(i), find(j)
+		if ri != rj {
+			parent[rj] = ri
+		}
+	}
+
+	byURL := make(map[string]int)
+	prints := make([]fingerprint, len(entries))
+
+	for i, entry := range entries {
+		if canonical := CanonicalURL(entry.URL); canonical != "" {
+			if j, ok := byURL[canonical]; ok {
+				union(j, i)
+			} else {
+				byURL[canonical] = i
+			}
+		}
+
+		titleWords := words(entry.Title)
+		if len(titleWords) >= options.MinTitleWords {
+			prints[i].title = simHash(titleWords, 1)
+			prints[i].hasTitle = true
+		}
+
+		contentWords := words(plainText(entry.Content))
+		if len(contentWords) >= options.MinContentWords {
+			prints[i].content = simHash(contentWords, 3)
+			prints[i].hasContent = true
+		}
+	}
+
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			if find(i) == find(j) {
+				continue
+			}
+
+			a, b := prints[i], prints[j]
+			if a.hasTitle && b.hasTitle && bits.OnesCount64(a.title^b.title) <= options.TitleDistance {
+				union(i, j)
+			} else if a.hasContent && b.hasContent && bits.OnesCount64(a.content^b.content) <= options.ContentDistance {
+				union(i, j)
+			}
+		}
+	}
+
+	groups := make(map[int][]Entry)
+	for i, entry := range entries {
+		root := find(i)
+		groups[root] = append(groups[root], entry)
+	}
+
+	var clusters []DuplicateCluster
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+
+		sort.Slice(group, func(i, j int) bool {
+			li, lj := len(group[i].Content), len(group[j].Content)
+			if li != lj {
+				return li > lj
+			}
+			if !group[i].Published.Equal(group[j].Published) {
+				return group[i].Published.Before(group[j].Published)
+			}
+			return group[i].ID < group[j].ID
+		})
+
+		clusters = append(clusters, DuplicateCluster{
+			Representative: group[0],
+			Duplicates:     group[1:],
+		})
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Representative.ID < clusters[j].Representative.ID
+	})
+
+	return clusters
+}
+
+// MarkDuplicatesAsRead marks every duplicate in clusters as read in batches
+// of MaxMutationIDs, leaving the representatives unread, and returns the IDs
+// that were marked. When a batch fails the IDs from earlier batches are
+// returned along with the error.
+func (c *Client) MarkDuplicatesAsRead(clusters []DuplicateCluster) ([]int64, error) {
+	var ids []int64
+	for _, cluster := range clusters {
+		ids = append(ids, cluster.DuplicateIDs()...)
+	}
+
+	var marked []int64
+	for _, batch := range batchIDs(ids, MaxMutationIDs) {
+		if err := c.MarkEntriesAsRead(batch); err != nil {
+			return marked, fmt.Errorf("error marking duplicates as read: %v", err)
+		}
+		marked = append(marked, batch...)
+	}
+
+	return marked, nil
+}
+
+// CanonicalURL normalises a URL so that links to the same page compare equal.
+// The scheme is dropped in favour of https, the host is lowercased without
+// "www." or a default port, tracking parameters and fragments are removed,
+// remaining parameters are sorted and trailing slashes are trimmed.
+func CanonicalURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	query := u.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "utm_") || trackingParams[lower] {
+			query.Del(key)
+		}
+	}
+
+	p := u.EscapedPath()
+	if p != "" {
+		p = path.Clean(p)
+	}
+	p = strings.TrimSuffix(p, "/")
+
+	canonical := "https://" + host + p
+	if encoded := query.Encode(); encoded != "" {
+		canonical += "?" + encoded
+	}
+
+	return canonical
+}
+
+// words splits text into lowercase words
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// simHash computes a 64-bit SimHash over shingles of size n
+func simHash(tokens []string, n int) uint64 {
+	if len(tokens) < n {
+		n = len(tokens)
+	}
+
+	var weights [64]int
+	for i := 0; i+n <= len(tokens); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(tokens[i:i+n], " ")))
+		sum := h.Sum64()
+
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			fingerprint |= 1 << uint(bit)
+		}
+	}
+
+	return fingerprint
+}
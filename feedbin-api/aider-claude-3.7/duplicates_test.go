@@ -0,0 +1,93 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	tests := map[string]string{
+		"http://www.Example.com/post/?utm_source=rss&utm_medium=feed": "https://example.com/post",
+		"https://example.com:443/post#comments":                       "https://example.com/post",
+		"https://example.com/a/../post?b=2&a=1&fbclid=xyz":            "https://example.com/post?a=1&b=2",
+		"https://example.com:8080/post":                               "https://example.com:8080/post",
+		"not a url":                                                   "",
+	}
+
+	for raw, expected := range tests {
+		if got := CanonicalURL(raw); got != expected {
+			t.Errorf("CanonicalURL(%q) = %q, expected %q", raw, got, expected)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	body := strings.Repeat("Apple announced today that it has agreed to acquire the digital magazine service Texture. ", 5)
+
+	entries := []Entry{
+		{ID: 1, FeedID: 1, Title: "Apple is buying Texture, the digital magazine distributor", URL: "https://www.recode.net/apple-texture?utm_source=twitter", Content: body},
+		{ID: 2, FeedID: 2, Title: "Apple is buying Texture, the digital magazine distributor", URL: "https://aggregator.example/item/42", Content: "<p>Short</p>"},
+		{ID: 3, FeedID: 3, Title: "Acquisition news", URL: "http://recode.net/apple-texture/", Content: ""},
+		{ID: 4, FeedID: 4, Title: "Mirror", URL: "https://mirror.example/texture", Content: "<div>" + body + " Read more.</div>"},
+		{ID: 5, FeedID: 1, Title: "Something else entirely different today", URL: "https://example.com/other", Content: strings.Repeat("Unrelated words about gardening and tomatoes grow. ", 10)},
+	}
+
+	clusters := FindDuplicates(entries, nil)
+	if len(clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(clusters))
+	}
+
+	cluster := clusters[0]
+	if cluster.Representative.ID != 4 {
+		t.Errorf("Expected entry 4 with the longest content to be the representative, got %d", cluster.Representative.ID)
+	}
+
+	ids := cluster.DuplicateIDs()
+	if len(ids) != 3 {
+		t.Fatalf("Expected 3 duplicates, got %v", ids)
+	}
+
+	for _, id := range ids {
+		if id == 5 {
+			t.Error("Expected entry 5 not to be a duplicate")
+		}
+	}
+}
+
+func TestMarkDuplicatesAsReadBatches(t *testing.T) {
+	var batches [][]int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req UnreadEntryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		batches = append(batches, req.UnreadEntries)
+		if len(batches) == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pass")
+	client.baseURL, _ = url.Parse(server.URL)
+
+	cluster := DuplicateCluster{Representative: Entry{ID: 1}}
+	for i := 2; i <= 1501; i++ {
+		cluster.Duplicates = append(cluster.Duplicates, Entry{ID: int64(i)})
+	}
+
+	marked, err := client.MarkDuplicatesAsRead([]DuplicateCluster{cluster})
+	if err == nil {
+		t.Fatal("Expected an error from the failed batch")
+	}
+
+	if len(batches) != 2 || len(batches[0]) != MaxMutationIDs || len(batches[1]) != 500 {
+		t.Fatalf("Expected batches of %d and 500 IDs, got %d batches", MaxMutationIDs, len(batches))
+	}
+
+	if len(marked) != MaxMutationIDs || marked[0] != 2 || marked[len(marked)-1] != 1001 {
+		t.Errorf("Expected only the first batch to be reported as marked, got %d IDs", len(marked))
+	}
+}
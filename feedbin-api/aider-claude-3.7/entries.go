@@ -3,16 +3,14 @@ package feedbin
 import (
 	"fmt"
 	"net/http"
-	"net/url"
-	"strconv"
 	"time"
 )
 
-// GetEntries retrieves entries with optional parameters
-func (c *Client) GetEntries(params url.Values) ([]Entry, error) {
-	path := "/v2/entries.json"
-	if len(params) > 0 {
-		path = fmt.Sprintf("%s?%s", path, params.Encode())
+// GetEntries retrieves entries matching an optional query
+func (c *Client) GetEntries(query *EntryQuery) ([]Entry, error) {
+	path, err := entriesPath("/v2/entries.json", query)
+	if err != nil {
+		return nil, err
 	}
 	
 	req, err := c.NewRequest(http.MethodGet, path, nil)
@@ -65,10 +63,14 @@ func (c *Client) GetEntry(id int64) (*Entry, error) {
 }
 
 // GetFeedEntries retrieves entries for a specific feed
-func (c *Client) GetFeedEntries(feedID int64, params url.Values) ([]Entry, error) {
-	path := fmt.Sprintf("/v2/feeds/%d/entries.json", feedID)
-	if len(params) > 0 {
-		path = fmt.Sprintf("%s?%s", path, params.Encode())
+func (c *Client) GetFeedEntries(feedID int64, query *EntryQuery) ([]Entry, error) {
+	if query != nil && len(query.IDs) > 0 {
+		return nil, fmt.Errorf("invalid entry query: ids is not supported for feed entries")
+	}
+	
+	path, err := entriesPath(fmt.Sprintf("/v2/feeds/%d/entries.json", feedID), query)
+	if err != nil {
+		return nil, err
 	}
 	
 	req, err := c.NewRequest(http.MethodGet, path, nil)
@@ -96,27 +98,29 @@ func (c *Client) GetFeedEntries(feedID int64, params url.Values) ([]Entry, error
 }
 
 // GetEntriesSince retrieves entries published since a specific time
-func (c *Client) GetEntriesSince(since time.Time, params url.Values) ([]Entry, error) {
-	if params == nil {
-		params = url.Values{}
-	}
-	params.Set("since", FormatFeedbinTime(since))
-	
-	return c.GetEntries(params)
+func (c *Client) GetEntriesSince(since time.Time, query *EntryQuery) ([]Entry, error) {
+	return c.GetEntries(query.clone().WithSince(since))
 }
 
-// GetEntriesByIDs retrieves entries by their IDs
+// GetEntriesByIDs retrieves entries by their IDs, in batches of MaxEntryIDs
 func (c *Client) GetEntriesByIDs(ids []int64) ([]Entry, error) {
-	if len(ids) == 0 {
-		return []Entry{}, nil
-	}
+	entries := []Entry{}
 	
-	params := url.Values{}
-	for _, id := range ids {
-		params.Add("ids", strconv.FormatInt(id, 10))
+	for start := 0; start < len(ids); start += MaxEntryIDs {
+		end := start + MaxEntryIDs
+		if end > len(ids) {
+			end = len(ids)
+		}
+		
+		batch, err := c.GetEntries(NewEntryQuery().WithIDs(ids[start:end]...))
+		if err != nil {
+			return nil, err
+		}
+		
+		entries = append(entries, batch...)
 	}
 	
-	return c.GetEntries(params)
+	return entries, nil
 }
 
 // GetEntryCount returns the total number of entries
@@ -140,16 +144,17 @@ func (c *Client) GetEntryCount() (int, error) {
 }
 
 // GetPaginatedEntries retrieves entries with pagination support
-func (c *Client) GetPaginatedEntries(params url.Values, page int) ([]Entry, map[string]string, error) {
-	if params == nil {
-		params = url.Values{}
+func (c *Client) GetPaginatedEntries(query *EntryQuery, page int) ([]Entry, map[string]string, error) {
+	query = query.clone()
+	if page > 0 {
+		query.WithPage(page)
 	}
 	
-	if page > 0 {
-		params.Set("page", strconv.Itoa(page))
+	path, err := entriesPath("/v2/entries.json", query)
+	if err != nil {
+		return nil, nil, err
 	}
 	
-	path := fmt.Sprintf("/v2/entries.json?%s", params.Encode())
 	req, err := c.NewRequest(http.MethodGet, path, nil)
 	if err != nil {
 		return nil, nil, err
@@ -0,0 +1,219 @@
+package feedbin
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxEntryIDs is the maximum number of entries that can be requested by ID at once
+	MaxEntryIDs = 100
+
+	// EntryModeExtended requests additional metadata for each entry
+	EntryModeExtended = "extended"
+)
+
+// EntryQuery holds the documented parameters for entry requests. The zero
+// value requests the first page of entries with the server defaults.
+type EntryQuery struct {
+	Page               int
+	Since              time.Time
+	IDs                []int64
+	Read               *bool
+	Starred            *bool
+	PerPage            int
+	Mode               string
+	IncludeOriginal    bool
+	IncludeEnclosure   bool
+	IncludeContentDiff bool
+}
+
+// NewEntryQuery returns an empty entry query
+func NewEntryQuery() *EntryQuery {
+	return &EntryQuery{}
+}
+
+// WithPage sets the page to request, starting at 1
+func (q *EntryQuery) WithPage(page int) *EntryQuery {
+	q.Page = page
+	return q
+}
+
+// WithSince limits results to entries created after since
+func (q *EntryQuery) WithSince(since time.Time) *EntryQuery {
+	q.Since = since
+	return q
+}
+
+// WithIDs limits results to the given entry IDs
+func (q *EntryQuery) WithIDs(ids ...int64) *EntryQuery {
+	q.IDs = append(q.IDs, ids...)
+	return q
+}
+
+// WithRead limits results to read or unread entries
+func (q *EntryQuery) WithRead(read bool) *EntryQuery {
+	q.Read = &read
+	return q
+}
+
+// WithStarred limits results to starred or unstarred entries
+func (q *EntryQuery) WithStarred(starred bool) *EntryQuery {
+	q.Starred = &starred
+	return q
+}
+
+// WithPerPage sets the number of entries per page
+func (q *EntryQuery) WithPerPage(perPage int) *EntryQuery {
+	q.PerPage = perPage
+	return q
+}
+
+// WithExtendedMode requests extended entry metadata
+func (q *EntryQuery) WithExtendedMode() *EntryQuery {
+	q.Mode = EntryModeExtended
+	return q
+}
+
+// WithOriginal includes the original entry data for updated entries
+func (q *EntryQuery) WithOriginal() *EntryQuery {
+	q.IncludeOriginal = true
+	return q
+}
+
+// WithEnclosure includes podcast/RSS enclosure data
+func (q *EntryQuery) WithEnclosure() *EntryQuery {
+	q.IncludeEnclosure = true
+	return q
+}
+
+// WithContentDiff includes an HTML diff of changed content
+func (q *EntryQuery) WithContentDiff() *EntryQuery {
+	q.IncludeContentDiff = true
+	return q
+}
+
+// Validate checks the query against the documented parameter constraints
+func (q *EntryQuery) Validate() error {
+	if q == nil {
+		return nil
+	}
+
+	var problems []string
+
+	if q.Page < 0 {
+		problems = append(problems, fmt.Sprintf("page must be 1 or greater, got %d", q.Page))
+	}
+
+	if q.PerPage < 0 {
+		problems = append(problems, fmt.Sprintf("per_page must be 1 or greater, got %d", q.PerPage))
+	}
+
+	if len(q.IDs) > MaxEntryIDs {
+		problems = append(problems, fmt.Sprintf("ids accepts at most %d IDs, got %d", MaxEntryIDs, len(q.IDs)))
+	}
+
+	for _, id := range q.IDs {
+		if id <= 0 {
+			problems = append(problems, fmt.Sprintf("ids must be positive, got %d", id))
+			break
+		}
+	}
+
+	if q.Mode != "" && q.Mode != EntryModeExtended {
+		problems = append(problems, fmt.Sprintf("mode must be %q, got %q", EntryModeExtended, q.Mode))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid entry query: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// Values validates the query and encodes it as URL parameters
+func (q *EntryQuery) Values() (url.Values, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	if q == nil {
+		return params, nil
+	}
+
+	if q.Page > 0 {
+		params.Set("page", strconv.Itoa(q.Page))
+	}
+
+	if !q.Since.IsZero() {
+		params.Set("since", FormatFeedbinTime(q.Since))
+	}
+
+	if len(q.IDs) > 0 {
+		ids := make([]string, len(q.IDs))
+		for i, id := range q.IDs {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		params.Set("ids", strings.Join(ids, ","))
+	}
+
+	if q.Read != nil {
+		params.Set("read", strconv.FormatBool(*q.Read))
+	}
+
+	if q.Starred != nil {
+		params.Set("starred", strconv.FormatBool(*q.Starred))
+	}
+
+	if q.PerPage > 0 {
+		params.Set("per_page", strconv.Itoa(q.PerPage))
+	}
+
+	if q.Mode != "" {
+		params.Set("mode", q.Mode)
+	}
+
+	if q.IncludeOriginal {
+		params.Set("include_original", "true")
+	}
+
+	if q.IncludeEnclosure {
+		params.Set("include_enclosure", "true")
+	}
+
+	if q.IncludeContentDiff {
+		params.Set("include_content_diff", "true")
+	}
+
+	return params, nil
+}
+
+// clone returns a copy of the query that can be modified without affecting the caller
+func (q *EntryQuery) clone() *EntryQuery {
+	if q == nil {
+		return NewEntryQuery()
+	}
+
+	c := *q
+	c.IDs = append([]int64(nil), q.IDs...)
+
+	return &c
+}
+
+// entriesPath returns path with the encoded query appended
+func entriesPath(path string, query *EntryQuery) (string, error) {
+	params, err := query.Values()
+	if err != nil {
+		return "", err
+	}
+
+	if len(params) > 0 {
+		path = fmt.Sprintf("%s?%s", path, params.Encode())
+	}
+
+	return path, nil
+}
@@ -0,0 +1,103 @@
+package feedbin
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntryQueryValues(t *testing.T) {
+	since := time.Date(2013, 2, 2, 14, 7, 33, 0, time.UTC)
+	query := NewEntryQuery().
+		WithPage(2).
+		WithSince(since).
+		WithIDs(1, 2, 3).
+		WithRead(false).
+		WithStarred(true).
+		WithPerPage(50).
+		WithExtendedMode().
+		WithOriginal().
+		WithEnclosure().
+		WithContentDiff()
+
+	params, err := query.Values()
+	if err != nil {
+		t.Fatalf("Values returned error: %v", err)
+	}
+
+	expected := map[string]string{
+		"page":                 "2",
+		"since":                "2013-02-02T14:07:33.000000Z",
+		"ids":                  "1,2,3",
+		"read":                 "false",
+		"starred":              "true",
+		"per_page":             "50",
+		"mode":                 "extended",
+		"include_original":     "true",
+		"include_enclosure":    "true",
+		"include_content_diff": "true",
+	}
+
+	if len(params) != len(expected) {
+		t.Errorf("Expected %d parameters, got %d: %v", len(expected), len(params), params)
+	}
+
+	for key, value := range expected {
+		if params.Get(key) != value {
+			t.Errorf("Expected %s to be '%s', got '%s'", key, value, params.Get(key))
+		}
+	}
+}
+
+func TestEntryQueryValidate(t *testing.T) {
+	ids := make([]int64, MaxEntryIDs+1)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+
+	tests := []struct {
+		name  string
+		query *EntryQuery
+		want  string
+	}{
+		{"too many ids", NewEntryQuery().WithIDs(ids...), "at most 100 IDs"},
+		{"negative id", NewEntryQuery().WithIDs(5, -1), "ids must be positive"},
+		{"negative page", NewEntryQuery().WithPage(-1), "page must be 1 or greater"},
+		{"negative per page", NewEntryQuery().WithPerPage(-10), "per_page must be 1 or greater"},
+		{"unknown mode", &EntryQuery{Mode: "compact"}, "mode must be"},
+	}
+
+	for _, tt := range tests {
+		err := tt.query.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing '%s', got %v", tt.name, tt.want, err)
+		}
+	}
+
+	var nilQuery *EntryQuery
+	if err := nilQuery.Validate(); err != nil {
+		t.Errorf("Expected nil query to be valid, got %v", err)
+	}
+}
+
+func TestGetFeedEntriesRejectsIDs(t *testing.T) {
+	client := NewClient("user", "pass")
+
+	_, err := client.GetFeedEntries(1, NewEntryQuery().WithIDs(1))
+	if err == nil {
+		t.Error("Expected error when requesting feed entries by ID")
+	}
+}
+
+func TestEntryQueryClone(t *testing.T) {
+	query := NewEntryQuery().WithPerPage(10)
+	clone := query.clone().WithSince(time.Now())
+
+	if !query.Since.IsZero() {
+		t.Error("Expected original query to be unchanged")
+	}
+
+	if clone.PerPage != 10 {
+		t.Errorf("Expected clone to keep per_page 10, got %d", clone.PerPage)
+	}
+}
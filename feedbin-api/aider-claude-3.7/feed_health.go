@@ -0,0 +1,299 @@
+package feedbin
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FeedHealthOptions controls how feed activity is judged
+type FeedHealthOptions struct {
+	// SilentAfter flags feeds that have not published for longer than this
+	SilentAfter time.Duration
+
+	// SampleSize is the number of recent entries analysed per feed
+	SampleSize int
+
+	// BurstVariation flags feeds whose posting intervals vary more than this
+	// many times their mean (coefficient of variation)
+	BurstVariation float64
+
+	// SummaryLength is the text length below which an entry counts as a summary
+	SummaryLength int
+
+	// Now is the reference time for the report, defaults to time.Now()
+	Now time.Time
+}
+
+// DefaultFeedHealthOptions returns the options used when none are given
+func DefaultFeedHealthOptions() FeedHealthOptions {
+	return FeedHealthOptions{
+		SilentAfter:    90 * 24 * time.Hour,
+		SampleSize:     DefaultPerPage,
+		BurstVariation: 1.5,
+		SummaryLength:  400,
+	}
+}
+
+// FeedHealth describes the posting activity of a single subscription
+type FeedHealth struct {
+	Subscription Subscription
+
+	// EntryCount is the number of entries analysed
+	EntryCount int
+
+	LastPublished time.Time
+
+	// AverageInterval is the mean time between posts in the sample
+	AverageInterval time.Duration
+
+	// AverageLength is the mean text length of entry content, in characters
+	AverageLength int
+
+	Silent      bool
+	Bursty      bool
+	SummaryOnly bool
+
+	// Error is set if the feed's entries could not be retrieved
+	Error string
+}
+
+// PostsPerWeek returns the posting cadence derived from AverageInterval
+func (h FeedHealth) PostsPerWeek() float64 {
+	if h.AverageInterval <= 0 {
+		return 0
+	}
+
+	return float64(7*24*time.Hour) / float64(h.AverageInterval)
+}
+
+// Flags returns a short description of each issue found with the feed
+func (h FeedHealth) Flags() []string {
+	var flags []string
+	if h.Silent {
+		flags = append(flags, "silent")
+	}
+	if h.Bursty {
+		flags = append(flags, "bursty")
+	}
+	if h.SummaryOnly {
+		flags = append(flags, "summary-only")
+	}
+	if h.Error != "" {
+		flags = append(flags, "error")
+	}
+
+	return flags
+}
+
+// FeedHealthReport is the result of analysing all subscriptions
+type FeedHealthReport struct {
+	GeneratedAt time.Time
+	Feeds       []FeedHealth
+
+	// SuggestedUnsubscribe lists the feeds that have gone silent
+	SuggestedUnsubscribe []FeedHealth
+}
+
+// AnalyzeFeed computes activity statistics for a subscription from its recent entries
+func AnalyzeFeed(subscription Subscription, entries []Entry, opts FeedHealthOptions) FeedHealth {
+	opts = opts.withDefaults()
+
+	health := FeedHealth{
+		Subscription:  subscription,
+		EntryCount:    len(entries),
+		LastPublished: subscription.LastPublishedAt,
+	}
+
+	published := make([]time.Time, 0, len(entries))
+	totalLength := 0
+	summaries := 0
+
+	for _, entry := range entries {
+		if !entry.Published.IsZero() {
+			published = append(published, entry.Published)
+			if entry.Published.After(health.LastPublished) {
+				health.LastPublished = entry.Published
+			}
+		}
+
+		length := len([]rune(plainText(entry.Content)))
+		totalLength += length
+		if length < opts.SummaryLength {
+			summaries++
+		}
+	}
+
+	if len(entries) > 0 {
+		health.AverageLength = totalLength / len(entries)
+		health.SummaryOnly = float64(summaries) >= 0.8*float64(len(entries))
+	}
+
+	sort.Slice(published, func(i, j int) bool { return published[i].Before(published[j]) })
+
+	if len(published) > 1 {
+		intervals := make([]float64, len(published)-1)
+		var sum float64
+		for i := 1; i < len(published); i++ {
+			intervals[i-1] = float64(published[i].Sub(published[i-1]))
+			sum += intervals[i-1]
+		}
+
+		mean := sum / float64(len(intervals))
+		health.AverageInterval = time.Duration(mean)
+
+		// Bursts need a few intervals to be distinguishable from noise
+		if len(intervals) >= 4 && mean > 0 {
+			var variance float64
+			for _, interval := range intervals {
+				variance += (interval - mean) * (interval - mean)
+			}
+			variance /= float64(len(intervals))
+
+			health.Bursty = math.Sqrt(variance)/mean > opts.BurstVariation
+		}
+	}
+
+	health.Silent = health.LastPublished.IsZero() || opts.Now.Sub(health.LastPublished) > opts.SilentAfter
+
+	return health
+}
+
+// GetFeedHealthReport analyses the recent entries of every subscription. Feeds
+// whose entries can't be retrieved are reported with Error set.
+func (c *Client) GetFeedHealthReport(opts *FeedHealthOptions) (*FeedHealthReport, error) {
+	options := DefaultFeedHealthOptions()
+	if opts != nil {
+		options = *opts
+	}
+	options = options.withDefaults()
+
+	subscriptions, err := c.GetSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FeedHealthReport{GeneratedAt: options.Now}
+
+	for _, subscription := range subscriptions {
+		entries, err := c.GetFeedEntries(subscription.FeedID, NewEntryQuery().WithPerPage(options.SampleSize))
+
+		health := AnalyzeFeed(subscription, entries, options)
+		if err != nil {
+			health.Error = err.Error()
+		}
+
+		report.Feeds = append(report.Feeds, health)
+		if health.Silent && health.Error == "" {
+			report.SuggestedUnsubscribe = append(report.SuggestedUnsubscribe, health)
+		}
+	}
+
+	return report, nil
+}
+
+// ApplyFeedHealthReport deletes the suggested subscriptions that confirm
+// approves and returns the IDs of the deleted subscriptions
+func (c *Client) ApplyFeedHealthReport(report *FeedHealthReport, confirm func(health FeedHealth) bool) ([]int64, error) {
+	if confirm == nil {
+		return nil, fmt.Errorf("a confirmation function is required to delete subscriptions")
+	}
+
+	var deleted []int64
+	for _, health := range report.SuggestedUnsubscribe {
+		if !confirm(health) {
+			continue
+		}
+
+		if err := c.DeleteSubscription(health.Subscription.ID); err != nil {
+			return deleted, fmt.Errorf("error deleting subscription %d: %v", health.Subscription.ID, err)
+		}
+
+		deleted = append(deleted, health.Subscription.ID)
+	}
+
+	return deleted, nil
+}
+
+// String renders the report as a plain-text table
+func (r *FeedHealthReport) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%-40s %8s %12s %10s %8s  %s\n", "FEED", "ENTRIES", "LAST POST", "POSTS/WK", "AVG LEN", "FLAGS")
+	for _, health := range r.Feeds {
+		last := "never"
+		if !health.LastPublished.IsZero() {
+			last = health.LastPublished.Format("2006-01-02")
+		}
+
+		fmt.Fprintf(&b, "%-40s %8d %12s %10.1f %8d  %s\n",
+			truncate(health.Subscription.Title, 40),
+			health.EntryCount,
+			last,
+			health.PostsPerWeek(),
+			health.AverageLength,
+			strings.Join(health.Flags(), ","))
+	}
+
+	if len(r.SuggestedUnsubscribe) > 0 {
+		fmt.Fprintf(&b, "\nSuggested unsubscribe:\n")
+		for _, health := range r.SuggestedUnsubscribe {
+			fmt.Fprintf(&b, "  - %s (%s)\n", health.Subscription.Title, health.Subscription.FeedURL)
+		}
+	}
+
+	return b.String()
+}
+
+// withDefaults fills in unset options
+func (o FeedHealthOptions) withDefaults() FeedHealthOptions {
+	defaults := DefaultFeedHealthOptions()
+
+	if o.SilentAfter <= 0 {
+		o.SilentAfter = defaults.SilentAfter
+	}
+	if o.SampleSize <= 0 {
+		o.SampleSize = defaults.SampleSize
+	}
+	if o.BurstVariation <= 0 {
+		o.BurstVariation = defaults.BurstVariation
+	}
+	if o.SummaryLength <= 0 {
+		o.SummaryLength = defaults.SummaryLength
+	}
+	if o.Now.IsZero() {
+		o.Now = time.Now()
+	}
+
+	return o
+}
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// plainText strips HTML tags and collapses whitespace
+func plainText(content string) string {
+	text := htmlTagPattern.ReplaceAllString(content, " ")
+	text = html.UnescapeString(text)
+
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(runes[:n])
+	}
+
+	return string(runes[:n-3]) + "..."
+}
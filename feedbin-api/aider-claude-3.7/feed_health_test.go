@@ -0,0 +1,102 @@
+package feedbin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeFeed(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	opts := FeedHealthOptions{Now: now}
+	longContent := "<p>" + strings.Repeat("word ", 200) + "</p>"
+
+	var steady []Entry
+	for i := 0; i < 10; i++ {
+		steady = append(steady, Entry{
+			Published: now.Add(-time.Duration(i) * 24 * time.Hour),
+			Content:   longContent,
+		})
+	}
+
+	health := AnalyzeFeed(Subscription{Title: "Steady"}, steady, opts)
+	if health.Silent || health.Bursty || health.SummaryOnly {
+		t.Errorf("Expected steady feed to have no flags, got %v", health.Flags())
+	}
+
+	if health.AverageInterval != 24*time.Hour {
+		t.Errorf("Expected average interval to be 24h, got %v", health.AverageInterval)
+	}
+
+	if perWeek := health.PostsPerWeek(); perWeek != 7 {
+		t.Errorf("Expected 7 posts per week, got %v", perWeek)
+	}
+
+	// Five posts within an hour, then nothing for a month
+	var bursty []Entry
+	for i := 0; i < 5; i++ {
+		bursty = append(bursty, Entry{
+			Published: now.Add(-time.Duration(i) * time.Minute),
+			Content:   "<p>Short summary</p>",
+		})
+	}
+	bursty = append(bursty, Entry{Published: now.Add(-30 * 24 * time.Hour), Content: "Short"})
+
+	health = AnalyzeFeed(Subscription{Title: "Bursty"}, bursty, opts)
+	if !health.Bursty {
+		t.Error("Expected feed to be flagged as bursty")
+	}
+	if !health.SummaryOnly {
+		t.Error("Expected feed to be flagged as summary-only")
+	}
+
+	old := []Entry{{Published: now.Add(-200 * 24 * time.Hour), Content: longContent}}
+	health = AnalyzeFeed(Subscription{Title: "Old"}, old, opts)
+	if !health.Silent {
+		t.Error("Expected feed to be flagged as silent")
+	}
+}
+
+func TestApplyFeedHealthReport(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pass")
+	client.baseURL, _ = url.Parse(server.URL)
+
+	report := &FeedHealthReport{
+		SuggestedUnsubscribe: []FeedHealth{
+			{Subscription: Subscription{ID: 1, Title: "Keep"}},
+			{Subscription: Subscription{ID: 2, Title: "Drop"}},
+		},
+	}
+
+	if _, err := client.ApplyFeedHealthReport(report, nil); err == nil {
+		t.Error("Expected error without a confirmation function")
+	}
+
+	ids, err := client.ApplyFeedHealthReport(report, func(health FeedHealth) bool {
+		return health.Subscription.Title == "Drop"
+	})
+	if err != nil {
+		t.Fatalf("ApplyFeedHealthReport returned error: %v", err)
+	}
+
+	if len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("Expected subscription 2 to be deleted, got %v", ids)
+	}
+
+	if len(deleted) != 1 || deleted[0] != "/v2/subscriptions/2.json" {
+		t.Errorf("Expected one delete request for subscription 2, got %v", deleted)
+	}
+}
@@ -5,6 +5,22 @@ import (
 	"net/http"
 )
 
+// GetIcons retrieves the favicons for all subscribed feeds
+func (c *Client) GetIcons() ([]Icon, error) {
+	req, err := c.NewRequest(http.MethodGet, "/v2/icons.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	
+	var icons []Icon
+	_, err = c.Do(req, &icons)
+	if err != nil {
+		return nil, err
+	}
+	
+	return icons, nil
+}
+
 // GetIcon retrieves a specific icon by ID
 func (c *Client) GetIcon(id int64) (*Favicon, error) {
 	path := fmt.Sprintf("/v2/icons/%d.json", id)
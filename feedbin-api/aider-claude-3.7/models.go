@@ -41,6 +41,16 @@ type Entry struct {
 	Published     time.Time `json:"published"`
 	CreatedAt     time.Time `json:"created_at"`
 	OriginalEntry map[string]interface{} `json:"original,omitempty"`
+	Enclosure     *Enclosure `json:"enclosure,omitempty"`
+}
+
+// Enclosure represents podcast/RSS enclosure data
+type Enclosure struct {
+	URL            string `json:"enclosure_url"`
+	Type           string `json:"enclosure_type"`
+	Length         string `json:"enclosure_length"`
+	ITunesDuration string `json:"itunes_duration,omitempty"`
+	ITunesImage    string `json:"itunes_image,omitempty"`
 }
 
 // UnreadEntry represents an unread entry ID
@@ -93,6 +103,12 @@ type Tag struct {
 	Count int    `json:"count,omitempty"`
 }
 
+// TagRenameRequest represents a request to rename a tag
+type TagRenameRequest struct {
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+}
+
 // SavedSearch represents a saved search
 type SavedSearch struct {
 	ID        int64     `json:"id"`
@@ -137,6 +153,12 @@ type Favicon struct {
 	Filename string `json:"filename,omitempty"`
 }
 
+// Icon represents the favicon for a feed host
+type Icon struct {
+	Host string `json:"host"`
+	URL  string `json:"url"`
+}
+
 // Import represents an OPML import
 type Import struct {
 	ID        int64     `json:"id"`
@@ -160,6 +182,12 @@ type Page struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// PageRequest represents a request to save a web page as an entry
+type PageRequest struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+}
+
 // ErrorResponse represents an error response from the API
 type ErrorResponse struct {
 	Status  int    `json:"status"`
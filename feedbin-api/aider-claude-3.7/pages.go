@@ -54,3 +54,25 @@ func (c *Client) GetEntryPages(entryID int64) ([]Page, error) {
 	
 	return pages, nil
 }
+
+// CreatePage saves a web page as a new entry. The title is only used if
+// Feedbin cannot find the title of the content.
+func (c *Client) CreatePage(pageURL, title string) (*Entry, error) {
+	pageReq := &PageRequest{
+		URL:   pageURL,
+		Title: title,
+	}
+	
+	req, err := c.NewRequest(http.MethodPost, "/v2/pages.json", pageReq)
+	if err != nil {
+		return nil, err
+	}
+	
+	entry := new(Entry)
+	_, err = c.Do(req, entry)
+	if err != nil {
+		return nil, err
+	}
+	
+	return entry, nil
+}
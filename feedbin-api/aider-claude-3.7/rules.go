@@ -0,0 +1,537 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule actions
+const (
+	ActionMarkRead = "mark_read"
+	ActionStar     = "star"
+	ActionSavePage = "save_page"
+)
+
+// MaxMutationIDs is the maximum number of entry IDs the API accepts in a
+// single unread or starred request
+const MaxMutationIDs = 1000
+
+// RuleSet is a declarative list of rules, usually loaded from a JSON file:
+//
+//	{
+//	  "rules": [
+//	    {"name": "mute crypto", "when": {"title": "(?i)crypto|nft"}, "actions": ["mark_read"]},
+//	    {"name": "releases", "when": {"tags": ["Tech"], "title": "(?i)release"}, "actions": ["star"]}
+//	  ]
+//	}
+type RuleSet struct {
+	Rules []*Rule `json:"rules"`
+}
+
+// Rule runs its actions on every entry matching all of its conditions
+type Rule struct {
+	Name    string         `json:"name"`
+	When    RuleConditions `json:"when"`
+	Actions []string       `json:"actions"`
+
+	// Stop prevents later rules from being evaluated for a matched entry
+	Stop bool `json:"stop,omitempty"`
+
+	title   *regexp.Regexp
+	content *regexp.Regexp
+	author  *regexp.Regexp
+}
+
+// RuleConditions are combined with AND. Empty conditions match every entry.
+type RuleConditions struct {
+	// FeedIDs matches entries from any of the given feeds
+	FeedIDs []int64 `json:"feed_ids,omitempty"`
+
+	// Tags matches entries from feeds tagged with any of the given names
+	Tags []string `json:"tags,omitempty"`
+
+	// Title, Content and Author are regular expressions. Content is matched
+	// against the entry's text with HTML removed.
+	Title   string `json:"title,omitempty"`
+	Content string `json:"content,omitempty"`
+	Author  string `json:"author,omitempty"`
+
+	// HasEnclosure matches entries with or without podcast/RSS enclosures
+	HasEnclosure *bool `json:"has_enclosure,omitempty"`
+
+	// OlderThan and NewerThan compare the entry's published date with the
+	// time of the run, e.g. "12h" or "7d"
+	OlderThan RuleDuration `json:"older_than,omitempty"`
+	NewerThan RuleDuration `json:"newer_than,omitempty"`
+}
+
+// RuleDuration is a duration written as a Go duration string, with an
+// additional "d" suffix for days
+type RuleDuration time.Duration
+
+// UnmarshalJSON parses durations such as "90m", "12h" or "7d"
+func (d *RuleDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %v", err)
+	}
+
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %s", s)
+		}
+		*d = RuleDuration(time.Duration(n * float64(24*time.Hour)))
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %s", s)
+	}
+	*d = RuleDuration(parsed)
+
+	return nil
+}
+
+// MarshalJSON writes the duration as a Go duration string
+func (d RuleDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadRules reads and compiles a rules file
+func LoadRules(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseRules(data)
+}
+
+// ParseRules parses and compiles rules from JSON
+func ParseRules(data []byte) (*RuleSet, error) {
+	rules := new(RuleSet)
+	if err := json.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("error parsing rules: %v", err)
+	}
+
+	if err := rules.Compile(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Compile validates every rule and compiles its regular expressions
+func (rs *RuleSet) Compile() error {
+	for i, rule := range rs.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		if len(rule.Actions) == 0 {
+			return fmt.Errorf("%s: no actions", rule.Name)
+		}
+
+		for _, action := range rule.Actions {
+			switch action {
+			case ActionMarkRead, ActionStar, ActionSavePage:
+			default:
+				return fmt.Errorf("%s: unknown action %q", rule.Name, action)
+			}
+		}
+
+		var err error
+		if rule.title, err = compilePattern(rule.When.Title); err != nil {
+			return fmt.Errorf("%s: invalid title pattern: %v", rule.Name, err)
+		}
+		if rule.content, err = compilePattern(rule.When.Content); err != nil {
+			return fmt.Errorf("%s: invalid content pattern: %v", rule.Name, err)
+		}
+		if rule.author, err = compilePattern(rule.When.Author); err != nil {
+			return fmt.Errorf("%s: invalid author pattern: %v", rule.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// usesTags reports whether any rule has a tag condition
+func (rs *RuleSet) usesTags() bool {
+	for _, rule := range rs.Rules {
+		if len(rule.When.Tags) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches reports whether entry satisfies every condition of the rule. tags
+// are the tag names of the entry's feed and now is the time of the run.
+func (r *Rule) Matches(entry Entry, tags []string, now time.Time) bool {
+	when := r.When
+
+	if len(when.FeedIDs) > 0 && !containsInt64(when.FeedIDs, entry.FeedID) {
+		return false
+	}
+
+	if len(when.Tags) > 0 {
+		found := false
+		for _, tag := range tags {
+			if containsString(when.Tags, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if r.title != nil && !r.title.MatchString(entry.Title) {
+		return false
+	}
+
+	if r.content != nil && !r.content.MatchString(plainText(entry.Content)) {
+		return false
+	}
+
+	if r.author != nil && !r.author.MatchString(entry.Author) {
+		return false
+	}
+
+	if when.HasEnclosure != nil && *when.HasEnclosure != (entry.Enclosure != nil && entry.Enclosure.URL != "") {
+		return false
+	}
+
+	age := now.Sub(entry.Published)
+	if when.OlderThan > 0 && age <= time.Duration(when.OlderThan) {
+		return false
+	}
+
+	if when.NewerThan > 0 && age >= time.Duration(when.NewerThan) {
+		return false
+	}
+
+	return true
+}
+
+// RuleMatch records which rule matched which entry
+type RuleMatch struct {
+	Rule    string   `json:"rule"`
+	EntryID int64    `json:"entry_id"`
+	FeedID  int64    `json:"feed_id"`
+	Title   string   `json:"title"`
+	Actions []string `json:"actions"`
+}
+
+// String describes the match for dry-run output
+func (m RuleMatch) String() string {
+	return fmt.Sprintf("%s: entry %d %q -> %s", m.Rule, m.EntryID, m.Title, strings.Join(m.Actions, ", "))
+}
+
+// RuleRun is the outcome of a single rules engine run
+type RuleRun struct {
+	Since   time.Time
+	Until   time.Time
+	Entries int
+	Matches []RuleMatch
+	DryRun  bool
+}
+
+// RuleEngine applies a rule set to entries created since its watermark
+type RuleEngine struct {
+	Rules *RuleSet
+
+	// DryRun reports matches without running any actions
+	DryRun bool
+
+	// StatePath is the file the since-watermark is persisted to. When empty
+	// the watermark is only kept in memory.
+	StatePath string
+
+	// InitialLookback is how far back the first run looks when no watermark exists
+	InitialLookback time.Duration
+
+	// Logger receives a line for every action taken, defaults to the standard logger
+	Logger *log.Logger
+
+	client *Client
+	since  time.Time
+}
+
+// ruleState is the persisted engine state
+type ruleState struct {
+	Since time.Time `json:"since"`
+}
+
+// NewRuleEngine returns a rules engine for client
+func NewRuleEngine(client *Client, rules *RuleSet) *RuleEngine {
+	return &RuleEngine{
+		Rules:           rules,
+		InitialLookback: 24 * time.Hour,
+		client:          client,
+	}
+}
+
+// Since returns the current watermark
+func (e *RuleEngine) Since() time.Time {
+	return e.since
+}
+
+// SetSince overrides the watermark for the next run
+func (e *RuleEngine) SetSince(since time.Time) {
+	e.since = since
+}
+
+// Run fetches every entry created since the watermark, evaluates the rules
+// and runs the matched actions. The watermark only advances when the run is
+// not a dry run and all actions succeed.
+func (e *RuleEngine) Run() (*RuleRun, error) {
+	if err := e.loadState(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	since := e.since
+	if since.IsZero() {
+		since = now.Add(-e.InitialLookback)
+	}
+
+	entries, err := e.fetchEntries(since)
+	if err != nil {
+		return nil, err
+	}
+
+	var feedTags map[int64][]string
+	if e.Rules.usesTags() {
+		taggings, err := e.client.GetTaggings()
+		if err != nil {
+			return nil, err
+		}
+
+		feedTags = make(map[int64][]string)
+		for _, tagging := range taggings {
+			feedTags[tagging.FeedID] = append(feedTags[tagging.FeedID], tagging.Name)
+		}
+	}
+
+	run := &RuleRun{Since: since, Entries: len(entries), DryRun: e.DryRun}
+	watermark := since
+
+	var readIDs, starIDs, pageIDs []int64
+	var pages []Entry
+
+	for _, entry := range entries {
+		if entry.CreatedAt.After(watermark) {
+			watermark = entry.CreatedAt
+		}
+
+		for _, rule := range e.Rules.Rules {
+			if !rule.Matches(entry, feedTags[entry.FeedID], now) {
+				continue
+			}
+
+			match := RuleMatch{
+				Rule:    rule.Name,
+				EntryID: entry.ID,
+				FeedID:  entry.FeedID,
+				Title:   entry.Title,
+				Actions: rule.Actions,
+			}
+			run.Matches = append(run.Matches, match)
+
+			for _, action := range rule.Actions {
+				switch action {
+				case ActionMarkRead:
+					readIDs = appendUnique(readIDs, entry.ID)
+				case ActionStar:
+					starIDs = appendUnique(starIDs, entry.ID)
+				case ActionSavePage:
+					if !containsInt64(pageIDs, entry.ID) {
+						pageIDs = append(pageIDs, entry.ID)
+						pages = append(pages, entry)
+					}
+				}
+			}
+
+			if rule.Stop {
+				break
+			}
+		}
+	}
+
+	run.Until = watermark
+
+	if e.DryRun {
+		for _, match := range run.Matches {
+			e.logf("dry-run: %s", match)
+		}
+		return run, nil
+	}
+
+	for _, ids := range batchIDs(readIDs, MaxMutationIDs) {
+		if err := e.client.MarkEntriesAsRead(ids); err != nil {
+			return run, fmt.Errorf("error marking entries as read: %v", err)
+		}
+		e.logf("%s: marked %d entries read: %v", ActionMarkRead, len(ids), ids)
+	}
+
+	for _, ids := range batchIDs(starIDs, MaxMutationIDs) {
+		if err := e.client.StarEntries(ids); err != nil {
+			return run, fmt.Errorf("error starring entries: %v", err)
+		}
+		e.logf("%s: starred %d entries: %v", ActionStar, len(ids), ids)
+	}
+
+	for _, entry := range pages {
+		if _, err := e.client.CreatePage(entry.URL, entry.Title); err != nil {
+			return run, fmt.Errorf("error saving page for entry %d: %v", entry.ID, err)
+		}
+		e.logf("%s: saved page for entry %d: %s", ActionSavePage, entry.ID, entry.URL)
+	}
+
+	e.since = watermark
+	if err := e.saveState(); err != nil {
+		return run, err
+	}
+
+	return run, nil
+}
+
+// fetchEntries retrieves every page of entries created since since
+func (e *RuleEngine) fetchEntries(since time.Time) ([]Entry, error) {
+	query := NewEntryQuery().WithSince(since).WithPerPage(DefaultPerPage).WithEnclosure()
+
+	var entries []Entry
+	for page := 1; ; page++ {
+		batch, links, err := e.client.GetPaginatedEntries(query, page)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, batch...)
+		if links["next"] == "" || len(batch) == 0 {
+			break
+		}
+	}
+
+	return entries, nil
+}
+
+// loadState reads the watermark from StatePath if it hasn't been set
+func (e *RuleEngine) loadState() error {
+	if e.StatePath == "" || !e.since.IsZero() {
+		return nil
+	}
+
+	data, err := os.ReadFile(e.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var state ruleState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("error reading rules state %s: %v", e.StatePath, err)
+	}
+
+	e.since = state.Since
+
+	return nil
+}
+
+// saveState writes the watermark to StatePath
+func (e *RuleEngine) saveState() error {
+	if e.StatePath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(ruleState{Since: e.since}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(e.StatePath, data)
+}
+
+// logf writes to the engine's logger
+func (e *RuleEngine) logf(format string, args ...interface{}) {
+	if e.Logger != nil {
+		e.Logger.Printf(format, args...)
+		return
+	}
+
+	log.Printf(format, args...)
+}
+
+// compilePattern compiles a non-empty regular expression
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	return regexp.Compile(pattern)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func containsInt64(values []int64, v int64) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendUnique(values []int64, v int64) []int64 {
+	if containsInt64(values, v) {
+		return values
+	}
+
+	return append(values, v)
+}
+
+// batchIDs splits ids into batches of at most size IDs
+func batchIDs(ids []int64, size int) [][]int64 {
+	var batches [][]int64
+	for start := 0; start < len(ids); start += size {
+		end := start + size
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batches = append(batches, ids[start:end])
+	}
+
+	return batches
+}
@@ -0,0 +1,206 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testRules = `{
+  "rules": [
+    {"name": "mute crypto", "when": {"title": "(?i)crypto"}, "actions": ["mark_read"], "stop": true},
+    {"name": "releases", "when": {"tags": ["Tech"], "title": "(?i)release"}, "actions": ["star"]},
+    {"name": "podcasts", "when": {"has_enclosure": true, "newer_than": "7d"}, "actions": ["star", "save_page"]}
+  ]
+}`
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatalf("ParseRules returned error: %v", err)
+	}
+
+	if len(rules.Rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %d", len(rules.Rules))
+	}
+
+	if time.Duration(rules.Rules[2].When.NewerThan) != 7*24*time.Hour {
+		t.Errorf("Expected newer_than to be 7 days, got %v", time.Duration(rules.Rules[2].When.NewerThan))
+	}
+
+	if _, err := ParseRules([]byte(`{"rules": [{"name": "bad", "actions": ["delete"]}]}`)); err == nil {
+		t.Error("Expected error for unknown action")
+	}
+
+	if _, err := ParseRules([]byte(`{"rules": [{"name": "bad", "when": {"title": "("}, "actions": ["star"]}]}`)); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestRuleEngineRun(t *testing.T) {
+	now := time.Now().UTC()
+	entries := []Entry{
+		{ID: 1, FeedID: 10, Title: "Crypto release party", Published: now, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: 2, FeedID: 10, Title: "Go 1.22 release", Published: now, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: 3, FeedID: 11, Title: "Episode 12", URL: "https://example.com/12", Published: now, CreatedAt: now.Add(-1 * time.Hour), Enclosure: &Enclosure{URL: "https://example.com/12.mp3"}},
+		{ID: 4, FeedID: 11, Title: "Unrelated", Published: now, CreatedAt: now.Add(-4 * time.Hour)},
+	}
+
+	var read, starred []int64
+	var pages []PageRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/entries.json":
+			json.NewEncoder(w).Encode(entries)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/taggings.json":
+			json.NewEncoder(w).Encode([]Tagging{{ID: 1, FeedID: 10, Name: "Tech"}})
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/unread_entries.json":
+			var req UnreadEntryRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			read = append(read, req.UnreadEntries...)
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/starred_entries.json":
+			var req StarredEntryRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			starred = append(starred, req.StarredEntries...)
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/pages.json":
+			var req PageRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			pages = append(pages, req)
+			w.Write([]byte("{}"))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pass")
+	client.baseURL, _ = url.Parse(server.URL)
+
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatalf("ParseRules returned error: %v", err)
+	}
+
+	engine := NewRuleEngine(client, rules)
+	engine.StatePath = filepath.Join(t.TempDir(), "rules-state.json")
+	engine.Logger = log.New(io.Discard, "", 0)
+	engine.DryRun = true
+
+	run, err := engine.Run()
+	if err != nil {
+		t.Fatalf("Dry run returned error: %v", err)
+	}
+
+	if len(run.Matches) != 3 {
+		t.Errorf("Expected 3 matches, got %v", run.Matches)
+	}
+
+	if len(read)+len(starred)+len(pages) != 0 {
+		t.Error("Expected dry run not to run any actions")
+	}
+
+	if !engine.Since().IsZero() {
+		t.Error("Expected dry run not to advance the watermark")
+	}
+
+	engine.DryRun = false
+	if _, err := engine.Run(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if len(read) != 1 || read[0] != 1 {
+		t.Errorf("Expected entry 1 to be marked read, got %v", read)
+	}
+
+	if len(starred) != 2 || starred[0] != 2 || starred[1] != 3 {
+		t.Errorf("Expected entries 2 and 3 to be starred, got %v", starred)
+	}
+
+	if len(pages) != 1 || pages[0].URL != "https://example.com/12" {
+		t.Errorf("Expected one page to be saved, got %v", pages)
+	}
+
+	if !engine.Since().Equal(entries[2].CreatedAt) {
+		t.Errorf("Expected watermark to be %v, got %v", entries[2].CreatedAt, engine.Since())
+	}
+
+	restored := NewRuleEngine(client, rules)
+	restored.StatePath = engine.StatePath
+	if err := restored.loadState(); err != nil {
+		t.Fatalf("loadState returned error: %v", err)
+	}
+
+	if !restored.Since().Equal(engine.Since()) {
+		t.Errorf("Expected persisted watermark %v, got %v", engine.Since(), restored.Since())
+	}
+}
+
+func TestRuleEngineRunBatchesAndDedupesActions(t *testing.T) {
+	now := time.Now().UTC()
+
+	var entries []Entry
+	for i := 1; i <= 1500; i++ {
+		entries = append(entries, Entry{ID: int64(i), FeedID: 10, Title: "Noise", Published: now, CreatedAt: now.Add(-time.Hour)})
+	}
+	entries[0].Title = "Keep this"
+	entries[0].URL = "https://example.com/keep"
+
+	var readBatches []int
+	var pages []PageRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/entries.json":
+			json.NewEncoder(w).Encode(entries)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/unread_entries.json":
+			var req UnreadEntryRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			readBatches = append(readBatches, len(req.UnreadEntries))
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/pages.json":
+			var req PageRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			pages = append(pages, req)
+			w.Write([]byte("{}"))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pass")
+	client.baseURL, _ = url.Parse(server.URL)
+
+	rules, err := ParseRules([]byte(`{
+  "rules": [
+    {"name": "read all", "actions": ["mark_read"]},
+    {"name": "keep", "when": {"title": "Keep"}, "actions": ["save_page"]},
+    {"name": "keep again", "when": {"title": "this"}, "actions": ["save_page"]}
+  ]
+}`))
+	if err != nil {
+		t.Fatalf("ParseRules returned error: %v", err)
+	}
+
+	engine := NewRuleEngine(client, rules)
+	engine.StatePath = filepath.Join(t.TempDir(), "rules-state.json")
+	engine.Logger = log.New(io.Discard, "", 0)
+
+	if _, err := engine.Run(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if len(readBatches) != 2 || readBatches[0] != MaxMutationIDs || readBatches[1] != 500 {
+		t.Errorf("Expected read batches of 1000 and 500 IDs, got %v", readBatches)
+	}
+
+	if len(pages) != 1 {
+		t.Errorf("Expected the page to be saved once, got %v", pages)
+	}
+}
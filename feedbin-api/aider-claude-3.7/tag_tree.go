@@ -0,0 +1,335 @@
+package feedbin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultTagSeparator separates folder levels in nested tag names such as "Tech/Go"
+const DefaultTagSeparator = "/"
+
+// TagNode represents a folder in a nested tag tree
+type TagNode struct {
+	// Name is the last segment of the path, e.g. "Go"
+	Name string
+
+	// Path is the full normalized tag name, e.g. "Tech/Go"
+	Path string
+
+	// Parent is nil for top-level folders
+	Parent   *TagNode
+	Children []*TagNode
+
+	// Taggings holds the taggings whose name resolves exactly to this folder
+	Taggings []Tagging
+
+	// UnreadCount is the number of unread entries in this folder and all of
+	// its children. Feeds that appear in several child folders are counted once.
+	UnreadCount int
+}
+
+// TagTree arranges flat tag names into folders
+type TagTree struct {
+	Separator string
+	Roots     []*TagNode
+
+	nodes map[string]*TagNode
+}
+
+// TagRename describes a single tag rename needed to move a folder
+type TagRename struct {
+	OldName string
+	NewName string
+
+	// Merge is set when NewName already exists outside the moved folder. Merges
+	// are carried out as tagging changes instead of a tag rename.
+	Merge bool
+}
+
+// BuildTagTree builds a tag tree from taggings, splitting names on separator.
+// unreadCounts maps feed IDs to their unread entry counts and may be nil.
+func BuildTagTree(taggings []Tagging, unreadCounts map[int64]int, separator string) *TagTree {
+	if separator == "" {
+		separator = DefaultTagSeparator
+	}
+
+	tree := &TagTree{
+		Separator: separator,
+		nodes:     make(map[string]*TagNode),
+	}
+
+	for _, tagging := range taggings {
+		segments := tree.split(tagging.Name)
+		if len(segments) == 0 {
+			continue
+		}
+
+		node := tree.ensure(segments)
+		node.Taggings = append(node.Taggings, tagging)
+	}
+
+	tree.sort(tree.Roots)
+	tree.Walk(func(node *TagNode) {
+		for _, feedID := range node.FeedIDs() {
+			node.UnreadCount += unreadCounts[feedID]
+		}
+	})
+
+	return tree
+}
+
+// GetTagTree retrieves all taggings and unread counts and arranges them into a tag tree
+func (c *Client) GetTagTree(separator string) (*TagTree, error) {
+	taggings, err := c.GetTaggings()
+	if err != nil {
+		return nil, err
+	}
+
+	unreadCounts, err := c.GetUnreadEntriesByFeed()
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildTagTree(taggings, unreadCounts, separator), nil
+}
+
+// Find returns the folder at path, or nil if it does not exist
+func (t *TagTree) Find(path string) *TagNode {
+	return t.nodes[t.normalize(path)]
+}
+
+// Walk calls fn for every folder in the tree, parents before children
+func (t *TagTree) Walk(fn func(node *TagNode)) {
+	var walk func(nodes []*TagNode)
+	walk = func(nodes []*TagNode) {
+		for _, node := range nodes {
+			fn(node)
+			walk(node.Children)
+		}
+	}
+
+	walk(t.Roots)
+}
+
+// FeedIDs returns the unique feed IDs tagged with this folder or any of its children
+func (n *TagNode) FeedIDs() []int64 {
+	seen := make(map[int64]bool)
+	var feedIDs []int64
+
+	var collect func(node *TagNode)
+	collect = func(node *TagNode) {
+		for _, tagging := range node.Taggings {
+			if !seen[tagging.FeedID] {
+				seen[tagging.FeedID] = true
+				feedIDs = append(feedIDs, tagging.FeedID)
+			}
+		}
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+
+	collect(n)
+	sort.Slice(feedIDs, func(i, j int) bool { return feedIDs[i] < feedIDs[j] })
+
+	return feedIDs
+}
+
+// TagNames returns the distinct tag names used in this folder and all of its children
+func (n *TagNode) TagNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+
+	var collect func(node *TagNode)
+	collect = func(node *TagNode) {
+		for _, tagging := range node.Taggings {
+			if !seen[tagging.Name] {
+				seen[tagging.Name] = true
+				names = append(names, tagging.Name)
+			}
+		}
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+
+	collect(n)
+
+	return names
+}
+
+// PlanTagMove returns the renames needed to move the folder at from, with all
+// of its children, to the folder at to
+func (t *TagTree) PlanTagMove(from, to string) ([]TagRename, error) {
+	node := t.Find(from)
+	if node == nil {
+		return nil, fmt.Errorf("tag folder not found: %s", from)
+	}
+
+	target := t.normalize(to)
+	if target == "" {
+		return nil, fmt.Errorf("invalid tag folder name: %q", to)
+	}
+	if target == node.Path {
+		return nil, nil
+	}
+	if strings.HasPrefix(target, node.Path+t.Separator) {
+		return nil, fmt.Errorf("cannot move tag folder %s into itself", node.Path)
+	}
+
+	// Names that move along with the folder can't be merge targets
+	moving := make(map[string]bool)
+	for _, name := range node.TagNames() {
+		moving[name] = true
+	}
+
+	var renames []TagRename
+	for _, name := range node.TagNames() {
+		suffix := strings.TrimPrefix(t.normalize(name), node.Path)
+		newName := target + suffix
+
+		merge := false
+		if existing := t.nodes[newName]; existing != nil {
+			for _, tagging := range existing.Taggings {
+				if !moving[tagging.Name] {
+					merge = true
+					break
+				}
+			}
+		}
+
+		renames = append(renames, TagRename{OldName: name, NewName: newName, Merge: merge})
+	}
+
+	return renames, nil
+}
+
+// MoveTagFolder moves the folder at from, with all of its children, to the
+// folder at to. Plain moves use tag renames; moves into existing tags are
+// carried out by retagging each feed. The renames that were applied are returned.
+func (c *Client) MoveTagFolder(tree *TagTree, from, to string) ([]TagRename, error) {
+	renames, err := tree.PlanTagMove(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, rename := range renames {
+		if rename.Merge {
+			err = c.mergeTag(tree, rename)
+		} else {
+			_, err = c.RenameTag(rename.OldName, rename.NewName)
+		}
+		if err != nil {
+			return renames[:i], fmt.Errorf("error moving tag %s to %s: %v", rename.OldName, rename.NewName, err)
+		}
+	}
+
+	return renames, nil
+}
+
+// RenameTagFolder renames the folder at path, keeping it under the same parent
+func (c *Client) RenameTagFolder(tree *TagTree, path, newName string) ([]TagRename, error) {
+	node := tree.Find(path)
+	if node == nil {
+		return nil, fmt.Errorf("tag folder not found: %s", path)
+	}
+
+	if strings.Contains(newName, tree.Separator) {
+		return nil, fmt.Errorf("folder name must not contain %q: %s", tree.Separator, newName)
+	}
+
+	to := newName
+	if node.Parent != nil {
+		to = node.Parent.Path + tree.Separator + newName
+	}
+
+	return c.MoveTagFolder(tree, path, to)
+}
+
+// mergeTag moves the feeds of one tag into an existing tag
+func (c *Client) mergeTag(tree *TagTree, rename TagRename) error {
+	tagged := make(map[int64]bool)
+	if existing := tree.Find(rename.NewName); existing != nil {
+		for _, tagging := range existing.Taggings {
+			tagged[tagging.FeedID] = true
+		}
+	}
+
+	source := tree.Find(rename.OldName)
+	if source == nil {
+		return nil
+	}
+
+	for _, tagging := range source.Taggings {
+		if tagging.Name != rename.OldName {
+			continue
+		}
+
+		if !tagged[tagging.FeedID] {
+			if _, err := c.CreateTagging(tagging.FeedID, rename.NewName); err != nil {
+				return err
+			}
+			tagged[tagging.FeedID] = true
+		}
+
+		if err := c.DeleteTagging(tagging.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// split breaks a tag name into trimmed, non-empty path segments
+func (t *TagTree) split(name string) []string {
+	var segments []string
+	for _, segment := range strings.Split(name, t.Separator) {
+		segment = strings.TrimSpace(segment)
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return segments
+}
+
+// normalize returns the canonical path for a tag name
+func (t *TagTree) normalize(name string) string {
+	return strings.Join(t.split(name), t.Separator)
+}
+
+// ensure returns the folder for segments, creating it and its parents as needed
+func (t *TagTree) ensure(segments []string) *TagNode {
+	var parent *TagNode
+	for i := range segments {
+		path := strings.Join(segments[:i+1], t.Separator)
+
+		node, ok := t.nodes[path]
+		if !ok {
+			node = &TagNode{Name: segments[i], Path: path, Parent: parent}
+			t.nodes[path] = node
+
+			if parent == nil {
+				t.Roots = append(t.Roots, node)
+			} else {
+				parent.Children = append(parent.Children, node)
+			}
+		}
+
+		parent = node
+	}
+
+	return parent
+}
+
+// sort orders folders by name at every level
+func (t *TagTree) sort(nodes []*TagNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return strings.ToLower(nodes[i].Name) < strings.ToLower(nodes[j].Name)
+	})
+
+	for _, node := range nodes {
+		t.sort(node.Children)
+	}
+}
@@ -0,0 +1,149 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func testTaggings() []Tagging {
+	return []Tagging{
+		{ID: 1, FeedID: 10, Name: "Tech/Go"},
+		{ID: 2, FeedID: 11, Name: "Tech/Rust"},
+		{ID: 3, FeedID: 10, Name: "Tech"},
+		{ID: 4, FeedID: 12, Name: "News"},
+		{ID: 5, FeedID: 13, Name: "Archive/Go"},
+	}
+}
+
+func TestBuildTagTree(t *testing.T) {
+	unread := map[int64]int{10: 3, 11: 2, 12: 7}
+	tree := BuildTagTree(testTaggings(), unread, "")
+
+	if tree.Separator != DefaultTagSeparator {
+		t.Errorf("Expected separator to be '%s', got '%s'", DefaultTagSeparator, tree.Separator)
+	}
+
+	if len(tree.Roots) != 3 {
+		t.Fatalf("Expected 3 root folders, got %d", len(tree.Roots))
+	}
+
+	if tree.Roots[0].Name != "Archive" || tree.Roots[1].Name != "News" || tree.Roots[2].Name != "Tech" {
+		t.Errorf("Expected roots to be sorted by name, got %s, %s, %s", tree.Roots[0].Name, tree.Roots[1].Name, tree.Roots[2].Name)
+	}
+
+	tech := tree.Find("Tech")
+	if tech == nil {
+		t.Fatal("Expected to find Tech folder")
+	}
+
+	if len(tech.Children) != 2 {
+		t.Errorf("Expected Tech to have 2 children, got %d", len(tech.Children))
+	}
+
+	// Feed 10 is tagged both Tech and Tech/Go, so it is only counted once
+	if tech.UnreadCount != 5 {
+		t.Errorf("Expected Tech unread count to be 5, got %d", tech.UnreadCount)
+	}
+
+	goNode := tree.Find(" Tech / Go ")
+	if goNode == nil || goNode.Parent != tech {
+		t.Fatal("Expected to find Tech/Go under Tech")
+	}
+
+	if feedIDs := tech.FeedIDs(); len(feedIDs) != 2 || feedIDs[0] != 10 || feedIDs[1] != 11 {
+		t.Errorf("Expected Tech feed IDs to be [10 11], got %v", feedIDs)
+	}
+}
+
+func TestPlanTagMove(t *testing.T) {
+	tree := BuildTagTree(testTaggings(), nil, "/")
+
+	renames, err := tree.PlanTagMove("Tech", "Archive")
+	if err != nil {
+		t.Fatalf("PlanTagMove returned error: %v", err)
+	}
+
+	expected := map[string]TagRename{
+		"Tech":      {OldName: "Tech", NewName: "Archive", Merge: false},
+		"Tech/Go":   {OldName: "Tech/Go", NewName: "Archive/Go", Merge: true},
+		"Tech/Rust": {OldName: "Tech/Rust", NewName: "Archive/Rust", Merge: false},
+	}
+
+	if len(renames) != len(expected) {
+		t.Fatalf("Expected %d renames, got %d", len(expected), len(renames))
+	}
+
+	for _, rename := range renames {
+		if rename != expected[rename.OldName] {
+			t.Errorf("Unexpected rename: %+v", rename)
+		}
+	}
+
+	if _, err := tree.PlanTagMove("Tech", "Tech/Old"); err == nil {
+		t.Error("Expected error when moving a folder into itself")
+	}
+
+	if _, err := tree.PlanTagMove("Missing", "Other"); err == nil {
+		t.Error("Expected error when moving a missing folder")
+	}
+}
+
+func TestMoveTagFolder(t *testing.T) {
+	var renamed []TagRenameRequest
+	var created []TaggingRequest
+	var deleted []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/tags.json":
+			var req TagRenameRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			renamed = append(renamed, req)
+			w.Write([]byte("[]"))
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/taggings.json":
+			var req TaggingRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			created = append(created, req)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("{}"))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pass")
+	client.baseURL, _ = url.Parse(server.URL)
+
+	tree := BuildTagTree(testTaggings(), nil, "/")
+	if _, err := client.RenameTagFolder(tree, "Tech/Rust", "Systems"); err != nil {
+		t.Fatalf("RenameTagFolder returned error: %v", err)
+	}
+
+	if len(renamed) != 1 || renamed[0].OldName != "Tech/Rust" || renamed[0].NewName != "Tech/Systems" {
+		t.Errorf("Expected Tech/Rust to be renamed to Tech/Systems, got %+v", renamed)
+	}
+
+	renamed = nil
+	if _, err := client.MoveTagFolder(tree, "Tech/Go", "Archive/Go"); err != nil {
+		t.Fatalf("MoveTagFolder returned error: %v", err)
+	}
+
+	if len(renamed) != 0 {
+		t.Errorf("Expected merge to avoid tag renames, got %+v", renamed)
+	}
+
+	if len(created) != 1 || created[0].FeedID != 10 || created[0].Name != "Archive/Go" {
+		t.Errorf("Expected feed 10 to be tagged Archive/Go, got %+v", created)
+	}
+
+	if len(deleted) != 1 || deleted[0] != "/v2/taggings/1.json" {
+		t.Errorf("Expected tagging 1 to be deleted, got %v", deleted)
+	}
+}
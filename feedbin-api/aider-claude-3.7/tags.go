@@ -33,6 +33,27 @@ func (c *Client) DeleteTag(id int64) error {
 	return err
 }
 
+// RenameTag renames a tag and returns the updated taggings
+func (c *Client) RenameTag(oldName, newName string) ([]Tagging, error) {
+	renameReq := &TagRenameRequest{
+		OldName: oldName,
+		NewName: newName,
+	}
+	
+	req, err := c.NewRequest(http.MethodPost, "/v2/tags.json", renameReq)
+	if err != nil {
+		return nil, err
+	}
+	
+	var taggings []Tagging
+	_, err = c.Do(req, &taggings)
+	if err != nil {
+		return nil, err
+	}
+	
+	return taggings, nil
+}
+
 // GetTagByName finds a tag by its name
 func (c *Client) GetTagByName(name string) (*Tag, error) {
 	tags, err := c.GetTags()
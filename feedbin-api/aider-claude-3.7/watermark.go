@@ -0,0 +1,406 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Watermark resources
+const (
+	WatermarkEntries        = "entries"
+	WatermarkSubscriptions  = "subscriptions"
+	WatermarkUpdatedEntries = "updated_entries"
+	WatermarkRecentlyRead   = "recently_read"
+)
+
+// DefaultWatermarkOverlap is how far each sync reaches back before the last
+// watermark to catch records that became visible late or were stamped by a
+// server clock that disagrees with the Date header
+const DefaultWatermarkOverlap = 5 * time.Minute
+
+// Watermark is the sync position for one resource
+type Watermark struct {
+	// Since is the newest server timestamp seen: the maximum created_at of the
+	// records returned, or the server's Date header when none were returned
+	Since time.Time `json:"since"`
+
+	// Seen maps the IDs returned by recent syncs to the server time they were
+	// seen at: their created_at, or the Date header for resources that only
+	// return IDs. IDs seen inside the overlap window before Since are filtered
+	// out when a later sync returns them again, and are dropped once they
+	// leave the window.
+	Seen map[int64]time.Time `json:"seen,omitempty"`
+}
+
+// WatermarkStore keeps a watermark per resource and persists it to a file
+type WatermarkStore struct {
+	// Path is the JSON file watermarks are saved to. When empty they are
+	// only kept in memory.
+	Path string
+
+	// Overlap is subtracted from each watermark before it is used as since
+	Overlap time.Duration
+
+	mu    sync.Mutex
+	marks map[string]*Watermark
+}
+
+// NewWatermarkStore loads the watermarks saved at path, if any
+func NewWatermarkStore(path string, overlap time.Duration) (*WatermarkStore, error) {
+	if overlap < 0 {
+		return nil, fmt.Errorf("watermark overlap must not be negative: %v", overlap)
+	}
+
+	store := &WatermarkStore{
+		Path:    path,
+		Overlap: overlap,
+		marks:   make(map[string]*Watermark),
+	}
+
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &store.marks); err != nil {
+		return nil, fmt.Errorf("error reading watermarks %s: %v", path, err)
+	}
+
+	return store, nil
+}
+
+// Get returns a copy of the watermark for resource
+func (s *WatermarkStore) Get(resource string) Watermark {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mark, ok := s.marks[resource]
+	if !ok {
+		return Watermark{}
+	}
+
+	seen := make(map[int64]time.Time, len(mark.Seen))
+	for id, at := range mark.Seen {
+		seen[id] = at
+	}
+
+	return Watermark{Since: mark.Since, Seen: seen}
+}
+
+// Since returns the since value for the next sync of resource: the
+// watermark minus the overlap window, or the zero time before the first sync
+func (s *WatermarkStore) Since(resource string) time.Time {
+	mark := s.Get(resource)
+	if mark.Since.IsZero() {
+		return time.Time{}
+	}
+
+	return mark.Since.Add(-s.Overlap)
+}
+
+// Unseen returns the IDs that were not already seen inside the overlap
+// window of the next sync
+func (s *WatermarkStore) Unseen(resource string, ids []int64) []int64 {
+	mark := s.Get(resource)
+	window := mark.Since.Add(-s.Overlap)
+
+	unseen := []int64{}
+	for _, id := range ids {
+		if at, ok := mark.Seen[id]; !ok || at.Before(window) {
+			unseen = append(unseen, id)
+		}
+	}
+
+	return unseen
+}
+
+// Advance moves the watermark for resource forward to next, records the
+// server time each ID in seen was seen at and persists the store. Watermarks
+// never move backwards, and IDs seen before the overlap window of the new
+// watermark are forgotten.
+func (s *WatermarkStore) Advance(resource string, next time.Time, seen map[int64]time.Time) error {
+	s.mu.Lock()
+
+	mark, ok := s.marks[resource]
+	if !ok {
+		mark = &Watermark{}
+		s.marks[resource] = mark
+	}
+
+	if next.After(mark.Since) {
+		mark.Since = next.UTC()
+	}
+
+	if mark.Seen == nil {
+		mark.Seen = make(map[int64]time.Time)
+	}
+	for id, at := range seen {
+		if at.After(mark.Seen[id]) {
+			mark.Seen[id] = at.UTC()
+		}
+	}
+
+	window := mark.Since.Add(-s.Overlap)
+	for id, at := range mark.Seen {
+		if at.Before(window) {
+			delete(mark.Seen, id)
+		}
+	}
+
+	s.mu.Unlock()
+
+	return s.save()
+}
+
+// replace sets the watermark for resource to next with exactly the IDs in
+// seen and persists the store
+func (s *WatermarkStore) replace(resource string, next time.Time, seen map[int64]time.Time) error {
+	s.mu.Lock()
+	s.marks[resource] = &Watermark{Since: next.UTC(), Seen: seen}
+	s.mu.Unlock()
+
+	return s.save()
+}
+
+// Reset forgets the watermark for resource so the next sync starts over
+func (s *WatermarkStore) Reset(resource string) error {
+	s.mu.Lock()
+	delete(s.marks, resource)
+	s.mu.Unlock()
+
+	return s.save()
+}
+
+// save writes the store to Path
+func (s *WatermarkStore) save() error {
+	if s.Path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.marks, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.Path, data)
+}
+
+// SyncEntries retrieves every entry created since the entries watermark,
+// skipping entries already returned by the previous sync, and advances the
+// watermark. query may add other entry parameters; its since and page are
+// managed by the sync.
+func (c *Client) SyncEntries(store *WatermarkStore, query *EntryQuery) ([]Entry, error) {
+	query = query.clone()
+	query.Since = store.Since(WatermarkEntries)
+
+	var entries []Entry
+	var serverTime time.Time
+
+	for page := 1; ; page++ {
+		path, err := entriesPath("/v2/entries.json", query.WithPage(page))
+		if err != nil {
+			return nil, err
+		}
+
+		var batch []Entry
+		resp, err := c.getForSync(path, &batch)
+		if err != nil {
+			return nil, err
+		}
+
+		if t, ok := ParseServerDate(resp); ok && t.After(serverTime) {
+			serverTime = t
+		}
+
+		entries = append(entries, batch...)
+		if GetPaginationLinks(resp)["next"] == "" || len(batch) == 0 {
+			break
+		}
+	}
+
+	ids := make([]int64, len(entries))
+	created := make([]time.Time, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+		created[i] = entry.CreatedAt
+	}
+
+	unseen := make(map[int64]bool)
+	for _, id := range store.Unseen(WatermarkEntries, ids) {
+		unseen[id] = true
+	}
+
+	fresh := []Entry{}
+	for _, entry := range entries {
+		if unseen[entry.ID] {
+			fresh = append(fresh, entry)
+		}
+	}
+
+	next, seen := nextWatermark(ids, created, serverTime)
+	if err := store.Advance(WatermarkEntries, next, seen); err != nil {
+		return nil, err
+	}
+
+	return fresh, nil
+}
+
+// SyncSubscriptions retrieves subscriptions created since the subscriptions
+// watermark and advances it
+func (c *Client) SyncSubscriptions(store *WatermarkStore) ([]Subscription, error) {
+	var subscriptions []Subscription
+	resp, err := c.getForSync(sincePath("/v2/subscriptions.json", store.Since(WatermarkSubscriptions)), &subscriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(subscriptions))
+	created := make([]time.Time, len(subscriptions))
+	for i, subscription := range subscriptions {
+		ids[i] = subscription.ID
+		created[i] = subscription.CreatedAt
+	}
+
+	unseen := make(map[int64]bool)
+	for _, id := range store.Unseen(WatermarkSubscriptions, ids) {
+		unseen[id] = true
+	}
+
+	fresh := []Subscription{}
+	for _, subscription := range subscriptions {
+		if unseen[subscription.ID] {
+			fresh = append(fresh, subscription)
+		}
+	}
+
+	serverTime, _ := ParseServerDate(resp)
+	next, seen := nextWatermark(ids, created, serverTime)
+	if err := store.Advance(WatermarkSubscriptions, next, seen); err != nil {
+		return nil, err
+	}
+
+	return fresh, nil
+}
+
+// SyncUpdatedEntries retrieves the IDs of entries updated since the updated
+// entries watermark and advances it
+func (c *Client) SyncUpdatedEntries(store *WatermarkStore) ([]int64, error) {
+	return c.syncIDs(store, WatermarkUpdatedEntries, "/v2/updated_entries.json")
+}
+
+// SyncRecentlyRead retrieves the IDs of recently read entries that were not
+// in the list returned by the previous sync. The endpoint has no since
+// parameter, so every sync fetches the full list and diffs it against the
+// previous one, which it then replaces. The watermark only records the
+// server time of the last sync.
+func (c *Client) SyncRecentlyRead(store *WatermarkStore) ([]int64, error) {
+	var ids []int64
+	resp, err := c.getForSync("/v2/recently_read_entries.json", &ids)
+	if err != nil {
+		return nil, err
+	}
+
+	serverTime, ok := ParseServerDate(resp)
+	if !ok {
+		return nil, fmt.Errorf("response for %s has no Date header to derive a watermark from", "/v2/recently_read_entries.json")
+	}
+
+	fresh := store.Unseen(WatermarkRecentlyRead, ids)
+	if err := store.replace(WatermarkRecentlyRead, serverTime, seenAt(ids, serverTime)); err != nil {
+		return nil, err
+	}
+
+	return fresh, nil
+}
+
+// syncIDs syncs a resource that only returns IDs. Without record timestamps
+// the watermark and the time each ID was seen come from the server's Date
+// header.
+func (c *Client) syncIDs(store *WatermarkStore, resource, path string) ([]int64, error) {
+	var ids []int64
+	resp, err := c.getForSync(sincePath(path, store.Since(resource)), &ids)
+	if err != nil {
+		return nil, err
+	}
+
+	serverTime, ok := ParseServerDate(resp)
+	if !ok {
+		return nil, fmt.Errorf("response for %s has no Date header to derive a watermark from", path)
+	}
+
+	fresh := store.Unseen(resource, ids)
+	if err := store.Advance(resource, serverTime, seenAt(ids, serverTime)); err != nil {
+		return nil, err
+	}
+
+	return fresh, nil
+}
+
+// seenAt records every ID in ids as seen at the same server time
+func seenAt(ids []int64, at time.Time) map[int64]time.Time {
+	seen := make(map[int64]time.Time, len(ids))
+	for _, id := range ids {
+		seen[id] = at
+	}
+
+	return seen
+}
+
+// getForSync performs a GET request and returns the response for its headers
+func (c *Client) getForSync(path string, v interface{}) (*http.Response, error) {
+	req, err := c.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(req, v)
+}
+
+// sincePath appends a since parameter to path unless since is zero
+func sincePath(path string, since time.Time) string {
+	if since.IsZero() {
+		return path
+	}
+
+	params := url.Values{}
+	params.Set("since", FormatFeedbinTime(since))
+
+	return path + "?" + params.Encode()
+}
+
+// nextWatermark returns the newest created_at among the records, or the
+// server time when there are none, along with the created_at of each record
+// to record as seen
+func nextWatermark(ids []int64, created []time.Time, serverTime time.Time) (time.Time, map[int64]time.Time) {
+	var next time.Time
+	for _, t := range created {
+		if t.After(next) {
+			next = t
+		}
+	}
+
+	if next.IsZero() {
+		next = serverTime
+	}
+
+	seen := make(map[int64]time.Time, len(ids))
+	for i, id := range ids {
+		seen[id] = created[i]
+	}
+
+	return next, seen
+}
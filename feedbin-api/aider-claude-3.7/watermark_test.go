@@ -0,0 +1,224 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseServerDate(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Date", "Sun, 02 Jun 2024 10:00:00 GMT")
+
+	serverTime, ok := ParseServerDate(resp)
+	if !ok {
+		t.Fatal("Expected Date header to be parsed")
+	}
+
+	expected := time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC)
+	if !serverTime.Equal(expected) {
+		t.Errorf("Expected server time to be %v, got %v", expected, serverTime)
+	}
+
+	if _, ok := ParseServerDate(&http.Response{Header: http.Header{}}); ok {
+		t.Error("Expected missing Date header not to parse")
+	}
+}
+
+func TestSyncEntriesWatermarks(t *testing.T) {
+	// The server clock runs an hour behind the local clock
+	serverNow := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	overlap := 10 * time.Minute
+
+	entries := []Entry{
+		{ID: 1, CreatedAt: serverNow.Add(-30 * time.Minute)},
+		{ID: 2, CreatedAt: serverNow.Add(-2 * time.Minute)},
+	}
+
+	var sinceValues []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", serverNow.Format(http.TimeFormat))
+
+		switch r.URL.Path {
+		case "/v2/entries.json":
+			since := r.URL.Query().Get("since")
+			sinceValues = append(sinceValues, since)
+
+			var result []Entry
+			for _, entry := range entries {
+				if since == "" {
+					result = append(result, entry)
+					continue
+				}
+				sinceTime, _ := ParseFeedbinTime(since)
+				if entry.CreatedAt.After(sinceTime) {
+					result = append(result, entry)
+				}
+			}
+			json.NewEncoder(w).Encode(result)
+		case "/v2/updated_entries.json":
+			json.NewEncoder(w).Encode([]int64{7, 8})
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pass")
+	client.baseURL, _ = url.Parse(server.URL)
+
+	statePath := filepath.Join(t.TempDir(), "watermarks.json")
+	store, err := NewWatermarkStore(statePath, overlap)
+	if err != nil {
+		t.Fatalf("NewWatermarkStore returned error: %v", err)
+	}
+
+	synced, err := client.SyncEntries(store, nil)
+	if err != nil {
+		t.Fatalf("SyncEntries returned error: %v", err)
+	}
+
+	if len(synced) != 2 {
+		t.Fatalf("Expected 2 entries on first sync, got %d", len(synced))
+	}
+
+	if mark := store.Get(WatermarkEntries); !mark.Since.Equal(entries[1].CreatedAt) {
+		t.Errorf("Expected watermark to be the newest created_at %v, got %v", entries[1].CreatedAt, mark.Since)
+	}
+
+	// A late entry created before the watermark but inside the overlap window
+	entries = append(entries, Entry{ID: 3, CreatedAt: serverNow.Add(-5 * time.Minute)})
+
+	restored, err := NewWatermarkStore(statePath, overlap)
+	if err != nil {
+		t.Fatalf("NewWatermarkStore returned error: %v", err)
+	}
+
+	synced, err = client.SyncEntries(restored, nil)
+	if err != nil {
+		t.Fatalf("SyncEntries returned error: %v", err)
+	}
+
+	if len(synced) != 1 || synced[0].ID != 3 {
+		t.Errorf("Expected only the late entry 3 on second sync, got %+v", synced)
+	}
+
+	expectedSince := FormatFeedbinTime(entries[1].CreatedAt.Add(-overlap))
+	if sinceValues[1] != expectedSince {
+		t.Errorf("Expected second sync since to be %s, got %s", expectedSince, sinceValues[1])
+	}
+
+	ids, err := client.SyncUpdatedEntries(restored)
+	if err != nil {
+		t.Fatalf("SyncUpdatedEntries returned error: %v", err)
+	}
+
+	if len(ids) != 2 {
+		t.Errorf("Expected 2 updated entries, got %v", ids)
+	}
+
+	if mark := restored.Get(WatermarkUpdatedEntries); !mark.Since.Equal(serverNow) {
+		t.Errorf("Expected updated entries watermark to be the server date %v, got %v", serverNow, mark.Since)
+	}
+
+	ids, err = client.SyncUpdatedEntries(restored)
+	if err != nil {
+		t.Fatalf("SyncUpdatedEntries returned error: %v", err)
+	}
+
+	if len(ids) != 0 {
+		t.Errorf("Expected repeated updated entries to be filtered, got %v", ids)
+	}
+
+	if offset := client.ServerNow().Sub(time.Now()); offset > -50*time.Minute {
+		t.Errorf("Expected ServerNow to follow the server clock, offset was %v", offset)
+	}
+}
+
+func TestWatermarkSeenIDsExpireWithOverlap(t *testing.T) {
+	overlap := 10 * time.Minute
+	store, err := NewWatermarkStore("", overlap)
+	if err != nil {
+		t.Fatalf("NewWatermarkStore returned error: %v", err)
+	}
+
+	start := time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC)
+	if err := store.Advance(WatermarkUpdatedEntries, start, map[int64]time.Time{5: start}); err != nil {
+		t.Fatalf("Advance returned error: %v", err)
+	}
+
+	// An empty sync inside the window keeps the ID
+	if err := store.Advance(WatermarkUpdatedEntries, start.Add(overlap/2), nil); err != nil {
+		t.Fatalf("Advance returned error: %v", err)
+	}
+
+	if unseen := store.Unseen(WatermarkUpdatedEntries, []int64{5, 6}); len(unseen) != 1 || unseen[0] != 6 {
+		t.Errorf("Expected only 6 to be unseen inside the window, got %v", unseen)
+	}
+
+	// Once the ID leaves the window it is forgotten
+	if err := store.Advance(WatermarkUpdatedEntries, start.Add(2*overlap), nil); err != nil {
+		t.Fatalf("Advance returned error: %v", err)
+	}
+
+	if mark := store.Get(WatermarkUpdatedEntries); len(mark.Seen) != 0 {
+		t.Errorf("Expected seen IDs outside the window to be dropped, got %v", mark.Seen)
+	}
+
+	if unseen := store.Unseen(WatermarkUpdatedEntries, []int64{5}); len(unseen) != 1 {
+		t.Errorf("Expected 5 to be unseen after leaving the window, got %v", unseen)
+	}
+}
+
+func TestSyncRecentlyReadDiffsFullList(t *testing.T) {
+	lists := [][]int64{{1, 2}, {2, 3}}
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/recently_read_entries.json" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.RawQuery != "" {
+			t.Errorf("Expected no query parameters, got %s", r.URL.RawQuery)
+		}
+
+		w.Header().Set("Date", time.Now().UTC().Format(http.TimeFormat))
+		json.NewEncoder(w).Encode(lists[requests])
+		requests++
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pass")
+	client.baseURL, _ = url.Parse(server.URL)
+
+	store, err := NewWatermarkStore("", DefaultWatermarkOverlap)
+	if err != nil {
+		t.Fatalf("NewWatermarkStore returned error: %v", err)
+	}
+
+	ids, err := client.SyncRecentlyRead(store)
+	if err != nil {
+		t.Fatalf("SyncRecentlyRead returned error: %v", err)
+	}
+
+	if len(ids) != 2 {
+		t.Errorf("Expected the full list on first sync, got %v", ids)
+	}
+
+	ids, err = client.SyncRecentlyRead(store)
+	if err != nil {
+		t.Fatalf("SyncRecentlyRead returned error: %v", err)
+	}
+
+	if len(ids) != 1 || ids[0] != 3 {
+		t.Errorf("Expected only entry 3 on second sync, got %v", ids)
+	}
+
+	if mark := store.Get(WatermarkRecentlyRead); len(mark.Seen) != 2 {
+		t.Errorf("Expected the seen IDs to be replaced by the latest list, got %v", mark.Seen)
+	}
+}
@@ -0,0 +1,303 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrPaginationDone is returned by CheckpointPaginator.Next once the last
+// page has been processed
+var ErrPaginationDone = errors.New("pagination complete")
+
+// CheckpointSeenIDs is the number of entry IDs a checkpoint keeps for
+// deduplication. Only the entries closest to the page watermark, the oldest
+// processed so far, are kept: entries arriving during a run shift older
+// entries down onto later pages, so only those can show up again. It covers
+// runs during which up to this many entries arrive.
+const CheckpointSeenIDs = 1000
+
+// Checkpoint is the saved position of a CheckpointPaginator
+type Checkpoint struct {
+	// Path is the endpoint being paginated, e.g. "entries.json"
+	Path string `json:"path"`
+
+	// Since is the lower bound the pagination was started with. It is kept
+	// fixed for the whole run so resumed pages come from the same result set.
+	Since string `json:"since,omitempty"`
+
+	// CurrentURL is the last page that was fully processed
+	CurrentURL string `json:"current_url,omitempty"`
+
+	// NextURL is the next page from the Link header of CurrentURL
+	NextURL string `json:"next_url,omitempty"`
+
+	// Pages is the number of pages processed so far
+	Pages int `json:"pages"`
+
+	// SeenIDs are the last CheckpointSeenIDs entries returned to the
+	// caller, oldest last
+	SeenIDs []int `json:"seen_ids"`
+
+	// Newest is the newest created_at seen, a good since for the next run
+	Newest time.Time `json:"newest,omitempty"`
+
+	// Done is set once the last page has been processed
+	Done bool `json:"done"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CheckpointPaginator walks every page of an entries endpoint and saves its
+// position to a state file after each page, so a long export can resume
+// where it stopped.
+//
+// Entries are sorted newest first, so entries arriving during the run push
+// older entries onto later pages and the same entry can show up twice.
+// Entries are deduplicated against SeenIDs, which keeps the entries nearest
+// the page watermark, and a resumed run fetches the last processed page again
+// so that nothing shifting back is skipped.
+type CheckpointPaginator struct {
+	client    *Client
+	statePath string
+	opts      *EntryOptions
+
+	checkpoint Checkpoint
+	seen       map[int]bool
+	resumed    bool
+}
+
+// NewCheckpointPaginator returns a paginator for path, e.g. "entries.json" or
+// "feeds/1/entries.json". A checkpoint saved at statePath is resumed; it must
+// have been created for the same path and since bound.
+func NewCheckpointPaginator(client *Client, path, statePath string, opts *EntryOptions) (*CheckpointPaginator, error) {
+	if statePath == "" {
+		return nil, errors.New("checkpoint state path is required")
+	}
+
+	if opts == nil {
+		opts = &EntryOptions{}
+	}
+
+	p := &CheckpointPaginator{
+		client:    client,
+		statePath: statePath,
+		opts:      opts,
+		seen:      make(map[int]bool),
+		checkpoint: Checkpoint{
+			Path:  path,
+			Since: opts.Since,
+		},
+	}
+
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("error reading checkpoint %s: %w", statePath, err)
+	}
+
+	if checkpoint.Path != path || checkpoint.Since != opts.Since {
+		return nil, fmt.Errorf("checkpoint %s was saved for %s since %q, not %s since %q",
+			statePath, checkpoint.Path, checkpoint.Since, path, opts.Since)
+	}
+
+	p.checkpoint = checkpoint
+	p.resumed = checkpoint.CurrentURL != ""
+	for _, id := range checkpoint.SeenIDs {
+		p.seen[id] = true
+	}
+
+	return p, nil
+}
+
+// Checkpoint returns a copy of the current position
+func (p *CheckpointPaginator) Checkpoint() Checkpoint {
+	checkpoint := p.checkpoint
+	checkpoint.SeenIDs = append([]int(nil), p.checkpoint.SeenIDs...)
+	return checkpoint
+}
+
+// Done reports whether the last page has been processed
+func (p *CheckpointPaginator) Done() bool {
+	return p.checkpoint.Done
+}
+
+// Next fetches the next page and returns the entries not seen before. The
+// checkpoint is saved before returning, so a caller that must not lose
+// entries should use Each instead. ErrPaginationDone is returned after the
+// last page.
+func (p *CheckpointPaginator) Next() ([]*Entry, *http.Response, error) {
+	entries, resp, pageURL, next, err := p.fetch()
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if err := p.advance(entries, pageURL, next); err != nil {
+		return nil, resp, err
+	}
+
+	return entries, resp, nil
+}
+
+// Each calls fn with the unseen entries of every remaining page. The
+// checkpoint is only saved after fn succeeds, so a page interrupted by an
+// error or a crash is delivered again on resume.
+func (p *CheckpointPaginator) Each(fn func(entries []*Entry) error) error {
+	for {
+		entries, _, pageURL, next, err := p.fetch()
+		if err == ErrPaginationDone {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(entries) > 0 {
+			if err := fn(entries); err != nil {
+				return err
+			}
+		}
+
+		if err := p.advance(entries, pageURL, next); err != nil {
+			return err
+		}
+	}
+}
+
+// Reset removes the state file so the next run starts from the first page
+func (p *CheckpointPaginator) Reset() error {
+	p.checkpoint = Checkpoint{Path: p.checkpoint.Path, Since: p.opts.Since}
+	p.seen = make(map[int]bool)
+	p.resumed = false
+
+	if err := os.Remove(p.statePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// fetch requests the page after the checkpoint and filters out seen entries
+func (p *CheckpointPaginator) fetch() ([]*Entry, *http.Response, string, string, error) {
+	if p.checkpoint.Done {
+		return nil, nil, "", "", ErrPaginationDone
+	}
+
+	pageURL, err := p.pageURL()
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	req, err := p.client.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	var entries []*Entry
+	resp, err := p.client.Do(req, &entries)
+	if err != nil {
+		return nil, resp, "", "", err
+	}
+
+	next := ""
+	links, err := ParseLinkHeader(resp)
+	if err != nil {
+		return nil, resp, "", "", err
+	}
+	if links != nil && links.NextURL != nil && len(entries) > 0 {
+		next = links.NextURL.String()
+	}
+
+	unseen := make([]*Entry, 0, len(entries))
+	for _, entry := range entries {
+		if !p.seen[entry.ID] {
+			unseen = append(unseen, entry)
+		}
+	}
+
+	return unseen, resp, pageURL, next, nil
+}
+
+// pageURL returns the URL of the page to fetch next
+func (p *CheckpointPaginator) pageURL() (string, error) {
+	switch {
+	case p.resumed:
+		// Fetch the last processed page again in case entries shifted
+		// back onto it since the checkpoint was saved
+		return p.checkpoint.CurrentURL, nil
+	case p.checkpoint.NextURL != "":
+		return p.checkpoint.NextURL, nil
+	default:
+		opts := *p.opts
+		opts.Page = nil
+		return AddQueryParams(p.checkpoint.Path, &opts)
+	}
+}
+
+// advance records a processed page and saves the checkpoint
+func (p *CheckpointPaginator) advance(entries []*Entry, pageURL, next string) error {
+	for _, entry := range entries {
+		p.seen[entry.ID] = true
+		p.checkpoint.SeenIDs = append(p.checkpoint.SeenIDs, entry.ID)
+		if entry.CreatedAt.After(p.checkpoint.Newest) {
+			p.checkpoint.Newest = entry.CreatedAt
+		}
+	}
+
+	if extra := len(p.checkpoint.SeenIDs) - CheckpointSeenIDs; extra > 0 {
+		for _, id := range p.checkpoint.SeenIDs[:extra] {
+			delete(p.seen, id)
+		}
+		p.checkpoint.SeenIDs = append([]int(nil), p.checkpoint.SeenIDs[extra:]...)
+	}
+
+	if p.resumed {
+		// A resumed run's first page is the old CurrentURL; its next link
+		// is still the saved one unless the Link header moved on
+		p.resumed = false
+		if next == "" {
+			next = p.checkpoint.NextURL
+		}
+	} else {
+		p.checkpoint.Pages++
+	}
+
+	p.checkpoint.CurrentURL = pageURL
+	p.checkpoint.NextURL = next
+	p.checkpoint.Done = next == ""
+	p.checkpoint.UpdatedAt = time.Now().UTC()
+
+	return p.save()
+}
+
+// save writes the checkpoint to the state file
+func (p *CheckpointPaginator) save() error {
+	data, err := json.MarshalIndent(p.checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(p.statePath); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := p.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, p.statePath)
+}
@@ -0,0 +1,155 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// pagedEntries serves entries newest first, two per page, with Link headers
+type pagedEntries struct {
+	ids []int
+}
+
+func (p *pagedEntries) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page == 0 {
+		page = 1
+	}
+
+	start, end := (page-1)*2, page*2
+	if end > len(p.ids) {
+		end = len(p.ids)
+	}
+
+	var entries []Entry
+	for _, id := range p.ids[start:end] {
+		entries = append(entries, Entry{ID: id, CreatedAt: time.Date(2024, 1, 1, 0, id, 0, 0, time.UTC)})
+	}
+
+	if end < len(p.ids) {
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s/v2/entries.json?page=%d>; rel="next"`, r.Host, page+1))
+	}
+	json.NewEncoder(w).Encode(entries)
+}
+
+func newCheckpointTestClient(t *testing.T, handler http.Handler) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClient("user", "pass")
+	client.BaseURL, _ = url.Parse(server.URL + "/v2/")
+	return client
+}
+
+func entryIDs(entries []*Entry) []int {
+	ids := make([]int, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	return ids
+}
+
+func TestCheckpointPaginatorResumesAndDedupes(t *testing.T) {
+	server := &pagedEntries{ids: []int{10, 9, 8, 7, 6}}
+	client := newCheckpointTestClient(t, server)
+	statePath := filepath.Join(t.TempDir(), "export.json")
+
+	paginator, err := NewCheckpointPaginator(client, "entries.json", statePath, nil)
+	if err != nil {
+		t.Fatalf("NewCheckpointPaginator returned error: %v", err)
+	}
+
+	// Stop after the first page, as if the export crashed
+	var delivered []int
+	stop := errors.New("stop")
+	err = paginator.Each(func(entries []*Entry) error {
+		if len(delivered) > 0 {
+			return stop
+		}
+		delivered = append(delivered, entryIDs(entries)...)
+		return nil
+	})
+	if err != stop {
+		t.Fatalf("Each error = %v, want %v", err, stop)
+	}
+
+	// A new entry arrives and shifts the others down a position
+	server.ids = []int{11, 10, 9, 8, 7, 6}
+
+	resumed, err := NewCheckpointPaginator(client, "entries.json", statePath, nil)
+	if err != nil {
+		t.Fatalf("NewCheckpointPaginator returned error: %v", err)
+	}
+	if checkpoint := resumed.Checkpoint(); checkpoint.Pages != 1 || checkpoint.NextURL == "" {
+		t.Fatalf("Resumed checkpoint = %+v, want one page processed", checkpoint)
+	}
+
+	err = resumed.Each(func(entries []*Entry) error {
+		delivered = append(delivered, entryIDs(entries)...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Each returned error: %v", err)
+	}
+
+	if want := []int{10, 9, 11, 8, 7, 6}; !reflect.DeepEqual(delivered, want) {
+		t.Errorf("Delivered entries %v, want %v", delivered, want)
+	}
+	if !resumed.Done() {
+		t.Error("Expected the paginator to be done")
+	}
+	if _, _, err := resumed.Next(); err != ErrPaginationDone {
+		t.Errorf("Next error = %v, want %v", err, ErrPaginationDone)
+	}
+}
+
+func TestCheckpointPaginatorRejectsOtherSince(t *testing.T) {
+	client := newCheckpointTestClient(t, &pagedEntries{ids: []int{2, 1}})
+	statePath := filepath.Join(t.TempDir(), "export.json")
+
+	paginator, _ := NewCheckpointPaginator(client, "entries.json", statePath, nil)
+	if _, _, err := paginator.Next(); err != nil {
+		t.Fatalf("Next returned error: %v", err)
+	}
+
+	opts := &EntryOptions{PageOptions: PageOptions{Since: "2024-01-01T00:00:00Z"}}
+	if _, err := NewCheckpointPaginator(client, "entries.json", statePath, opts); err == nil {
+		t.Error("Expected a checkpoint for another since bound to be rejected")
+	}
+}
+
+func TestCheckpointPaginatorBoundsSeenIDs(t *testing.T) {
+	ids := make([]int, CheckpointSeenIDs+3)
+	for i := range ids {
+		ids[i] = len(ids) - i
+	}
+
+	// Serve everything on one page, then an empty last page
+	client := newCheckpointTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries []Entry
+		for _, id := range ids {
+			entries = append(entries, Entry{ID: id})
+		}
+		json.NewEncoder(w).Encode(entries)
+	}))
+
+	paginator, _ := NewCheckpointPaginator(client, "entries.json", filepath.Join(t.TempDir(), "export.json"), nil)
+	if _, _, err := paginator.Next(); err != nil {
+		t.Fatalf("Next returned error: %v", err)
+	}
+
+	seen := paginator.Checkpoint().SeenIDs
+	if len(seen) != CheckpointSeenIDs || seen[0] != CheckpointSeenIDs || seen[len(seen)-1] != 1 {
+		t.Errorf("Expected the %d oldest IDs to be kept, got %d IDs from %d to %d",
+			CheckpointSeenIDs, len(seen), seen[0], seen[len(seen)-1])
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 )
 
@@ -36,9 +37,16 @@ type Client struct {
 	// User agent used for API requests
 	UserAgent string
 
-	// Authentication credentials
-	username string
-	password string
+	// CredentialsTTL caches resolved credentials for this long. By default
+	// the provider is asked for every request; set a TTL for providers that
+	// are slow to resolve, such as VaultCredentials or CommandCredentials.
+	CredentialsTTL time.Duration
+
+	// Authentication credentials, resolved from the provider per request
+	credentials   CredentialsProvider
+	credentialsMu sync.Mutex
+	resolved      *Credentials
+	resolvedAt    time.Time
 
 	// Services used for communicating with different API endpoints
 	Authentication *AuthenticationService
@@ -49,18 +57,27 @@ type Client struct {
 	Tags           *TagsService
 	Taggings       *TaggingsService
 	SavedSearches  *SavedSearchesService
+	Extract        *ExtractService
+
+	// Journal records unread and starred mutations so they can be undone
+	Journal *Journal
 }
 
 // NewClient creates a new Feedbin API client
 func NewClient(username, password string) *Client {
+	return NewClientWithProvider(StaticCredentials{Username: username, Password: password})
+}
+
+// NewClientWithProvider creates a new Feedbin API client that resolves its
+// credentials from provider for each request
+func NewClientWithProvider(provider CredentialsProvider) *Client {
 	baseURL, _ := url.Parse(DefaultBaseURL)
 
 	c := &Client{
-		client:    http.DefaultClient,
-		BaseURL:   baseURL,
-		UserAgent: UserAgent,
-		username:  username,
-		password:  password,
+		client:      http.DefaultClient,
+		BaseURL:     baseURL,
+		UserAgent:   UserAgent,
+		credentials: provider,
 	}
 
 	// Initialize services
@@ -72,6 +89,7 @@ func NewClient(username, password string) *Client {
 	c.Tags = &TagsService{client: c}
 	c.Taggings = &TaggingsService{client: c}
 	c.SavedSearches = &SavedSearchesService{client: c}
+	c.Extract = &ExtractService{client: c, Host: DefaultExtractHost}
 
 	return c
 }
@@ -105,18 +123,33 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", c.UserAgent)
-	req.SetBasicAuth(c.username, c.password)
+
+	if err := c.setBasicAuth(req); err != nil {
+		return nil, err
+	}
 
 	return req, nil
 }
 
-// Do sends an API request and returns the API response
+// Do sends an API request and returns the API response. After a 401 the
+// credentials are resolved again, and the request is retried once if they
+// changed.
 func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		if retry := c.retryWithNewCredentials(req); retry != nil {
+			resp.Body.Close()
+			resp, err = c.client.Do(retry)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	defer resp.Body.Close()
 
 	err = CheckResponse(resp)
@@ -138,6 +171,82 @@ func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
 	return resp, err
 }
 
+// SetCredentialsProvider replaces the credentials provider. The new
+// provider is resolved on the next request.
+func (c *Client) SetCredentialsProvider(provider CredentialsProvider) {
+	c.credentialsMu.Lock()
+	defer c.credentialsMu.Unlock()
+
+	c.credentials = provider
+	c.resolved = nil
+}
+
+// setBasicAuth adds the resolved credentials to a request
+func (c *Client) setBasicAuth(req *http.Request) error {
+	creds, err := c.resolveCredentials(false)
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(creds.Username, creds.Password)
+	return nil
+}
+
+// resolveCredentials asks the provider for credentials. Credentials cached
+// within CredentialsTTL are returned instead unless refresh is set.
+func (c *Client) resolveCredentials(refresh bool) (*Credentials, error) {
+	c.credentialsMu.Lock()
+	defer c.credentialsMu.Unlock()
+
+	if c.resolved != nil && !refresh && time.Since(c.resolvedAt) < c.CredentialsTTL {
+		return c.resolved, nil
+	}
+
+	if c.credentials == nil {
+		return nil, errors.New("no credentials provider configured")
+	}
+
+	creds, err := c.credentials.Credentials()
+	if err != nil {
+		return nil, fmt.Errorf("error resolving credentials: %w", err)
+	}
+
+	c.resolved = creds
+	c.resolvedAt = time.Now()
+	return creds, nil
+}
+
+// retryWithNewCredentials returns a copy of an unauthorized request with
+// freshly resolved credentials, or nil when they have not changed or the
+// request cannot be sent again
+func (c *Client) retryWithNewCredentials(req *http.Request) *http.Request {
+	username, password, ok := req.BasicAuth()
+	if !ok {
+		return nil
+	}
+
+	creds, err := c.resolveCredentials(true)
+	if err != nil || (creds.Username == username && creds.Password == password) {
+		return nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			return nil
+		}
+		retry.Body = body
+	}
+
+	retry.SetBasicAuth(creds.Username, creds.Password)
+	return retry
+}
+
 // ErrorResponse represents an error response from the Feedbin API
 type ErrorResponse struct {
 	Response *http.Response
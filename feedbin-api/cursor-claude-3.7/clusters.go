@@ -0,0 +1,422 @@
+package feedbin
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultClusterIterations bounds the k-means refinement rounds
+	DefaultClusterIterations = 20
+	// DefaultClusterKeywords is the number of keywords per cluster
+	DefaultClusterKeywords = 5
+	// DefaultClusterRepresentatives is the number of representative entries
+	// per cluster
+	DefaultClusterRepresentatives = 3
+)
+
+// ClusterOptions configures topic clustering
+type ClusterOptions struct {
+	// K is the number of clusters; zero picks the square root of half the
+	// number of entries
+	K int
+
+	MaxIterations   int
+	Keywords        int
+	Representatives int
+}
+
+// termWeight is one term of a sparse vector
+type termWeight struct {
+	term   string
+	weight float64
+}
+
+// termVector is a sparse vector sorted by term, so that sums over it are
+// always done in the same order
+type termVector []termWeight
+
+// TopicCluster is a group of entries about the same topic
+type TopicCluster struct {
+	// Keywords are the terms with the highest weight in the cluster. The
+	// cluster of entries without usable text has none.
+	Keywords []string
+
+	// Entries are ordered by similarity to the cluster, closest first
+	Entries []*Entry
+
+	// Representatives are the entries closest to the cluster centre
+	Representatives []*Entry
+}
+
+// EntryIDs returns the IDs of the cluster's entries
+func (c *TopicCluster) EntryIDs() []int {
+	ids := make([]int, len(c.Entries))
+	for i, entry := range c.Entries {
+		ids[i] = entry.ID
+	}
+	return ids
+}
+
+// ClusterEntries groups entries into topics using TF-IDF vectors of their
+// title and text and spherical k-means. Entries are processed in ID order
+// and centres are seeded farthest-first, so the same entries always give
+// the same clusters. Clusters are returned largest first.
+func ClusterEntries(entries []*Entry, opts *ClusterOptions) []*TopicCluster {
+	if opts == nil {
+		opts = &ClusterOptions{}
+	}
+
+	sorted := append([]*Entry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	vectors := tfidf(sorted)
+
+	var docs []int
+	var unclustered []*Entry
+	for i := range sorted {
+		if len(vectors[i]) == 0 {
+			unclustered = append(unclustered, sorted[i])
+		} else {
+			docs = append(docs, i)
+		}
+	}
+
+	k := opts.K
+	if k <= 0 {
+		k = int(math.Ceil(math.Sqrt(float64(len(docs)) / 2)))
+	}
+	if k > len(docs) {
+		k = len(docs)
+	}
+
+	iterations := opts.MaxIterations
+	if iterations <= 0 {
+		iterations = DefaultClusterIterations
+	}
+
+	keywords := opts.Keywords
+	if keywords <= 0 {
+		keywords = DefaultClusterKeywords
+	}
+
+	representatives := opts.Representatives
+	if representatives <= 0 {
+		representatives = DefaultClusterRepresentatives
+	}
+
+	var clusters []*TopicCluster
+	if k > 0 {
+		centroids := seedCentroids(vectors, docs, k)
+		assignment := make(map[int]int)
+
+		for round := 0; round < iterations; round++ {
+			changed := false
+			for _, doc := range docs {
+				best := nearestCentroid(vectors[doc], centroids)
+				if current, ok := assignment[doc]; !ok || current != best {
+					assignment[doc] = best
+					changed = true
+				}
+			}
+
+			if !changed {
+				break
+			}
+
+			members := make([][]int, len(centroids))
+			for _, doc := range docs {
+				members[assignment[doc]] = append(members[assignment[doc]], doc)
+			}
+			for c := range centroids {
+				if len(members[c]) > 0 {
+					centroids[c] = centroid(vectors, members[c])
+				}
+			}
+		}
+
+		for c, center := range centroids {
+			var members []int
+			for _, doc := range docs {
+				if assignment[doc] == c {
+					members = append(members, doc)
+				}
+			}
+			if len(members) == 0 {
+				continue
+			}
+
+			sort.SliceStable(members, func(i, j int) bool {
+				return cosine(vectors[members[i]], center) > cosine(vectors[members[j]], center)
+			})
+
+			cluster := &TopicCluster{Keywords: topTerms(center, keywords)}
+			for _, doc := range members {
+				cluster.Entries = append(cluster.Entries, sorted[doc])
+			}
+
+			n := representatives
+			if n > len(cluster.Entries) {
+				n = len(cluster.Entries)
+			}
+			cluster.Representatives = cluster.Entries[:n]
+
+			clusters = append(clusters, cluster)
+		}
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return len(clusters[i].Entries) > len(clusters[j].Entries)
+	})
+
+	if len(unclustered) > 0 {
+		n := representatives
+		if n > len(unclustered) {
+			n = len(unclustered)
+		}
+		clusters = append(clusters, &TopicCluster{Entries: unclustered, Representatives: unclustered[:n]})
+	}
+
+	return clusters
+}
+
+// ClusterUnread clusters the current unread entries
+func (c *Client) ClusterUnread(opts *ClusterOptions) ([]*TopicCluster, error) {
+	ids, _, err := c.Unread.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for start := 0; start < len(ids); start += 100 {
+		end := start + 100
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batch, _, err := c.Entries.GetByIDs(ids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, batch...)
+	}
+
+	return ClusterEntries(entries, opts), nil
+}
+
+// MarkClusterRead marks every entry of a cluster as read in batches of
+// 1000 and returns the IDs the server confirmed
+func (c *Client) MarkClusterRead(cluster *TopicCluster) ([]int, error) {
+	ids := cluster.EntryIDs()
+
+	var marked []int
+	for start := 0; start < len(ids); start += 1000 {
+		end := start + 1000
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batch, _, err := c.Unread.MarkAsRead(ids[start:end])
+		marked = append(marked, batch...)
+		if err != nil {
+			return marked, err
+		}
+	}
+
+	return marked, nil
+}
+
+// tfidf returns an L2-normalised TF-IDF vector per entry. Title terms count
+// twice, and terms that occur in a single entry are dropped since they
+// cannot relate entries to each other.
+func tfidf(entries []*Entry) []termVector {
+	counts := make([]map[string]float64, len(entries))
+	df := make(map[string]int)
+
+	for i, entry := range entries {
+		counts[i] = make(map[string]float64)
+
+		if entry.Title != nil {
+			for _, term := range clusterTerms(*entry.Title) {
+				counts[i][term] += 2
+			}
+		}
+
+		text := ""
+		if entry.Content != nil {
+			text = *entry.Content
+		} else if entry.Summary != nil {
+			text = *entry.Summary
+		}
+		for _, term := range clusterTerms(htmlTagPattern.ReplaceAllString(text, " ")) {
+			counts[i][term]++
+		}
+
+		for term := range counts[i] {
+			df[term]++
+		}
+	}
+
+	n := float64(len(entries))
+	vectors := make([]termVector, len(entries))
+	for i, terms := range counts {
+		// Counts are whole numbers, so the total is exact in any order
+		total := 0.0
+		for _, count := range terms {
+			total += count
+		}
+
+		var vector termVector
+		for term, count := range terms {
+			if df[term] < 2 {
+				continue
+			}
+			vector = append(vector, termWeight{term, count / total * (math.Log((1+n)/(1+float64(df[term]))) + 1)})
+		}
+
+		sort.Slice(vector, func(a, b int) bool { return vector[a].term < vector[b].term })
+		vectors[i] = normalize(vector)
+	}
+
+	return vectors
+}
+
+var clusterTermPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// clusterStopWords are words too common in titles and text to tell topics
+// apart. Words of two letters or less are dropped separately.
+var clusterStopWords = map[string]bool{
+	"about": true, "after": true, "all": true, "also": true, "and": true,
+	"are": true, "but": true, "can": true, "for": true, "from": true,
+	"had": true, "has": true, "have": true, "how": true, "into": true,
+	"its": true, "more": true, "new": true, "not": true, "one": true,
+	"our": true, "out": true, "than": true, "that": true, "the": true,
+	"their": true, "there": true, "they": true, "this": true, "was": true,
+	"were": true, "what": true, "when": true, "which": true, "who": true,
+	"why": true, "will": true, "with": true, "you": true, "your": true,
+}
+
+// clusterTerms returns the lower-cased words of text without stop words
+func clusterTerms(text string) []string {
+	var terms []string
+	for _, term := range clusterTermPattern.FindAllString(strings.ToLower(text), -1) {
+		if len(term) > 2 && !clusterStopWords[term] {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// seedCentroids picks k starting centres farthest-first: the first
+// document, then repeatedly the document least similar to every centre
+// chosen so far
+func seedCentroids(vectors []termVector, docs []int, k int) []termVector {
+	centroids := []termVector{vectors[docs[0]]}
+	closest := make(map[int]float64)
+	for _, doc := range docs {
+		closest[doc] = cosine(vectors[doc], centroids[0])
+	}
+
+	for len(centroids) < k {
+		next, lowest := -1, math.Inf(1)
+		for _, doc := range docs {
+			if closest[doc] < lowest {
+				next, lowest = doc, closest[doc]
+			}
+		}
+
+		centroids = append(centroids, vectors[next])
+		for _, doc := range docs {
+			if sim := cosine(vectors[doc], vectors[next]); sim > closest[doc] {
+				closest[doc] = sim
+			}
+		}
+	}
+
+	return centroids
+}
+
+// nearestCentroid returns the most similar centre, the first on ties
+func nearestCentroid(vector termVector, centroids []termVector) int {
+	best, bestSim := 0, math.Inf(-1)
+	for c, center := range centroids {
+		if sim := cosine(vector, center); sim > bestSim {
+			best, bestSim = c, sim
+		}
+	}
+	return best
+}
+
+// centroid returns the normalised mean of the member vectors
+func centroid(vectors []termVector, members []int) termVector {
+	sum := make(map[string]float64)
+	for _, doc := range members {
+		for _, tw := range vectors[doc] {
+			sum[tw.term] += tw.weight
+		}
+	}
+
+	vector := make(termVector, 0, len(sum))
+	for term, weight := range sum {
+		vector = append(vector, termWeight{term, weight})
+	}
+	sort.Slice(vector, func(a, b int) bool { return vector[a].term < vector[b].term })
+
+	return normalize(vector)
+}
+
+// cosine returns the dot product of two normalised vectors
+func cosine(a, b termVector) float64 {
+	dot := 0.0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].term < b[j].term:
+			i++
+		case a[i].term > b[j].term:
+			j++
+		default:
+			dot += a[i].weight * b[j].weight
+			i++
+			j++
+		}
+	}
+	return dot
+}
+
+// normalize scales a vector to unit length
+func normalize(vector termVector) termVector {
+	norm := 0.0
+	for _, tw := range vector {
+		norm += tw.weight * tw.weight
+	}
+
+	if norm == 0 {
+		return vector
+	}
+
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i].weight /= norm
+	}
+	return vector
+}
+
+// topTerms returns the n heaviest terms, alphabetically on ties
+func topTerms(vector termVector, n int) []string {
+	sorted := append(termVector(nil), vector...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].weight > sorted[j].weight
+	})
+
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+
+	terms := make([]string, len(sorted))
+	for i, tw := range sorted {
+		terms[i] = tw.term
+	}
+	return terms
+}
@@ -0,0 +1,90 @@
+package feedbin
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func clusterTestEntries() []*Entry {
+	return []*Entry{
+		{ID: 1, Title: String("Rust compiler release"), Content: String("<p>The rust compiler ships faster builds</p>")},
+		{ID: 2, Title: String("Football league final"), Content: String("The football league final ended in penalties")},
+		{ID: 3, Title: String("Rust compiler internals"), Content: String("How the rust compiler checks borrows")},
+		{ID: 4, Title: String("Football transfer news"), Content: String("The league transfer window for football clubs")},
+		{ID: 5, Title: String("Rust async compiler"), Content: String("Async rust and the compiler")},
+		{ID: 6, Title: String("Untitled")},
+	}
+}
+
+func TestClusterEntries(t *testing.T) {
+	clusters := ClusterEntries(clusterTestEntries(), &ClusterOptions{K: 2, Keywords: 2})
+
+	if len(clusters) != 3 {
+		t.Fatalf("ClusterEntries returned %d clusters, want 2 topics and the unclustered entries", len(clusters))
+	}
+
+	if ids := clusters[0].EntryIDs(); !reflect.DeepEqual(sortedInts(ids), []int{1, 3, 5}) {
+		t.Errorf("First cluster = %v, want the rust entries", ids)
+	}
+	if !reflect.DeepEqual(clusters[0].Keywords, []string{"compiler", "rust"}) {
+		t.Errorf("First cluster keywords = %v, want [compiler rust]", clusters[0].Keywords)
+	}
+	if ids := clusters[1].EntryIDs(); !reflect.DeepEqual(sortedInts(ids), []int{2, 4}) {
+		t.Errorf("Second cluster = %v, want the football entries", ids)
+	}
+	if ids := clusters[2].EntryIDs(); !reflect.DeepEqual(ids, []int{6}) || clusters[2].Keywords != nil {
+		t.Errorf("Last cluster = %v with keywords %v, want the entry without usable text", ids, clusters[2].Keywords)
+	}
+}
+
+func TestClusterEntriesIsDeterministic(t *testing.T) {
+	entries := clusterTestEntries()
+	want := ClusterEntries(entries, nil)
+
+	// Input order does not matter
+	reversed := make([]*Entry, len(entries))
+	for i, entry := range entries {
+		reversed[len(entries)-1-i] = entry
+	}
+
+	for i := 0; i < 5; i++ {
+		got := ClusterEntries(reversed, nil)
+		if len(got) != len(want) {
+			t.Fatalf("ClusterEntries returned %d clusters, want %d", len(got), len(want))
+		}
+		for c := range got {
+			if !reflect.DeepEqual(got[c].EntryIDs(), want[c].EntryIDs()) || !reflect.DeepEqual(got[c].Keywords, want[c].Keywords) {
+				t.Errorf("Cluster %d = %v %v, want %v %v", c, got[c].EntryIDs(), got[c].Keywords, want[c].EntryIDs(), want[c].Keywords)
+			}
+		}
+	}
+}
+
+func TestMarkClusterRead(t *testing.T) {
+	state := &fakeEntryState{unread: map[int]bool{}, starred: map[int]bool{}}
+	cluster := &TopicCluster{}
+	for id := 1; id <= 1200; id++ {
+		state.unread[id] = true
+		cluster.Entries = append(cluster.Entries, &Entry{ID: id})
+	}
+	client := newJournalTestClient(t, state)
+
+	marked, err := client.MarkClusterRead(cluster)
+	if err != nil {
+		t.Fatalf("MarkClusterRead returned error: %v", err)
+	}
+
+	if len(marked) != 1200 || len(state.unread) != 0 {
+		t.Errorf("MarkClusterRead marked %d entries, %d still unread", len(marked), len(state.unread))
+	}
+	if !reflect.DeepEqual(state.batches, []int{1000, 200}) {
+		t.Errorf("Batches = %v, want [1000 200]", state.batches)
+	}
+}
+
+func sortedInts(ids []int) []int {
+	sorted := append([]int(nil), ids...)
+	sort.Ints(sorted)
+	return sorted
+}
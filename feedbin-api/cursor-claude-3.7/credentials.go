@@ -0,0 +1,392 @@
+package feedbin
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultUsernameEnv is the environment variable read for the username
+	DefaultUsernameEnv = "FEEDBIN_USERNAME"
+	// DefaultPasswordEnv is the environment variable read for the password
+	DefaultPasswordEnv = "FEEDBIN_PASSWORD"
+	// DefaultNetrcMachine is the machine looked up in the netrc file
+	DefaultNetrcMachine = "api.feedbin.com"
+	// DefaultVaultIterations is the PBKDF2 iteration count for new vaults
+	DefaultVaultIterations = 600000
+)
+
+// ErrVaultPassphrase is returned when a vault cannot be decrypted with the
+// passphrase, either because it is wrong or the file was modified
+var ErrVaultPassphrase = errors.New("vault passphrase is incorrect or the vault is corrupt")
+
+// Credentials are the username and password used for Basic authentication
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// CredentialsProvider supplies API credentials. The client resolves them
+// for every request, or once per Client.CredentialsTTL, and again after a
+// 401 response, so a provider can return rotated credentials without
+// restarting.
+type CredentialsProvider interface {
+	Credentials() (*Credentials, error)
+}
+
+// StaticCredentials always returns the same username and password
+type StaticCredentials Credentials
+
+// Credentials returns the static credentials
+func (p StaticCredentials) Credentials() (*Credentials, error) {
+	return &Credentials{Username: p.Username, Password: p.Password}, nil
+}
+
+// EnvCredentials reads credentials from environment variables
+type EnvCredentials struct {
+	// UsernameVar defaults to FEEDBIN_USERNAME
+	UsernameVar string
+	// PasswordVar defaults to FEEDBIN_PASSWORD
+	PasswordVar string
+}
+
+// Credentials returns the credentials from the environment
+func (p EnvCredentials) Credentials() (*Credentials, error) {
+	usernameVar := p.UsernameVar
+	if usernameVar == "" {
+		usernameVar = DefaultUsernameEnv
+	}
+
+	passwordVar := p.PasswordVar
+	if passwordVar == "" {
+		passwordVar = DefaultPasswordEnv
+	}
+
+	username, password := os.Getenv(usernameVar), os.Getenv(passwordVar)
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("environment variables %s and %s must be set", usernameVar, passwordVar)
+	}
+
+	return &Credentials{Username: username, Password: password}, nil
+}
+
+// NetrcCredentials reads credentials from a netrc file
+type NetrcCredentials struct {
+	// Path defaults to ~/.netrc
+	Path string
+	// Machine defaults to api.feedbin.com. A default entry is used when no
+	// machine entry matches.
+	Machine string
+}
+
+// Credentials returns the login and password of the matching netrc entry
+func (p NetrcCredentials) Credentials() (*Credentials, error) {
+	path := p.Path
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+
+	machine := p.Machine
+	if machine == "" {
+		machine = DefaultNetrcMachine
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := parseNetrc(data, machine)
+	if creds == nil || creds.Username == "" || creds.Password == "" {
+		return nil, fmt.Errorf("no login and password for %s in %s", machine, path)
+	}
+
+	return creds, nil
+}
+
+// parseNetrc returns the entry for machine, falling back to the default entry
+func parseNetrc(data []byte, machine string) *Credentials {
+	var match, fallback, current *Credentials
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	inMacro := false
+	var tokens []string
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Macro definitions run until the next blank line
+		if inMacro {
+			if strings.TrimSpace(line) == "" {
+				inMacro = false
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			if strings.HasPrefix(fields[i], "#") {
+				break
+			}
+			if fields[i] == "macdef" {
+				inMacro = true
+				break
+			}
+			tokens = append(tokens, fields[i])
+		}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+
+		switch tokens[i] {
+		case "machine":
+			current = &Credentials{}
+			if next == machine && match == nil {
+				match = current
+			}
+			i++
+		case "default":
+			current = &Credentials{}
+			if fallback == nil {
+				fallback = current
+			}
+		case "login":
+			if current != nil {
+				current.Username = next
+			}
+			i++
+		case "password":
+			if current != nil {
+				current.Password = next
+			}
+			i++
+		case "account":
+			i++
+		}
+	}
+
+	if match != nil {
+		return match
+	}
+
+	return fallback
+}
+
+// CommandCredentials runs an external command, such as a password manager
+// CLI, and reads the credentials from its output
+type CommandCredentials struct {
+	// Command is the program and its arguments
+	Command []string
+
+	// Username is used when set, and the command only prints the password.
+	// Otherwise the first line of output is the username and the second
+	// line the password.
+	Username string
+}
+
+// Credentials runs the command and parses its output
+func (p CommandCredentials) Credentials() (*Credentials, error) {
+	if len(p.Command) == 0 {
+		return nil, errors.New("credentials command is empty")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(p.Command[0], p.Command[1:]...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credentials command %s failed: %v: %s", p.Command[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	lines := strings.Split(strings.TrimRight(string(output), "\r\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+
+	if p.Username != "" {
+		if lines[0] == "" {
+			return nil, fmt.Errorf("credentials command %s printed no password", p.Command[0])
+		}
+		return &Credentials{Username: p.Username, Password: lines[0]}, nil
+	}
+
+	if len(lines) < 2 || lines[0] == "" || lines[1] == "" {
+		return nil, fmt.Errorf("credentials command %s must print a username and a password on separate lines", p.Command[0])
+	}
+
+	return &Credentials{Username: lines[0], Password: lines[1]}, nil
+}
+
+// VaultCredentials reads credentials from a local file encrypted with
+// AES-256-GCM under a key derived from a passphrase with PBKDF2-SHA256
+type VaultCredentials struct {
+	Path string
+
+	// Passphrase returns the vault passphrase, e.g. from a prompt or a
+	// keyring; it is called every time the vault is opened
+	Passphrase func() ([]byte, error)
+}
+
+// vaultFile is the on-disk vault format
+type vaultFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Credentials decrypts the vault
+func (p VaultCredentials) Credentials() (*Credentials, error) {
+	if p.Passphrase == nil {
+		return nil, errors.New("vault passphrase source is required")
+	}
+
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var vault vaultFile
+	if err := json.Unmarshal(data, &vault); err != nil {
+		return nil, fmt.Errorf("error reading vault %s: %w", p.Path, err)
+	}
+	if vault.Version != 1 {
+		return nil, fmt.Errorf("unsupported vault version %d in %s", vault.Version, p.Path)
+	}
+
+	passphrase, err := p.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := vaultCipher(passphrase, vault.Salt, vault.Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, vault.Nonce, vault.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrVaultPassphrase
+	}
+
+	creds := new(Credentials)
+	if err := json.Unmarshal(plaintext, creds); err != nil {
+		return nil, fmt.Errorf("error reading vault %s: %w", p.Path, err)
+	}
+
+	return creds, nil
+}
+
+// WriteVault encrypts credentials with passphrase and writes them to path,
+// replacing any existing vault
+func WriteVault(path string, passphrase []byte, creds *Credentials) error {
+	if len(passphrase) == 0 {
+		return errors.New("vault passphrase must not be empty")
+	}
+
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+
+	vault := vaultFile{
+		Version:    1,
+		Iterations: DefaultVaultIterations,
+		Salt:       make([]byte, 16),
+	}
+
+	if _, err := rand.Read(vault.Salt); err != nil {
+		return err
+	}
+
+	gcm, err := vaultCipher(passphrase, vault.Salt, vault.Iterations)
+	if err != nil {
+		return err
+	}
+
+	vault.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(vault.Nonce); err != nil {
+		return err
+	}
+	vault.Ciphertext = gcm.Seal(nil, vault.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(vault, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// vaultCipher derives the vault key and returns its AES-GCM cipher
+func vaultCipher(passphrase, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 || len(salt) == 0 {
+		return nil, errors.New("vault key parameters are missing")
+	}
+
+	block, err := aes.NewCipher(pbkdf2SHA256(passphrase, salt, iterations, 32))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 derives a key as described in RFC 8018
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+
+	key := make([]byte, 0, blocks*hashLen)
+	buf := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf, uint32(block))
+		prf.Write(buf)
+		u := prf.Sum(nil)
+
+		t := make([]byte, len(u))
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+
+		key = append(key, t...)
+	}
+
+	return key[:keyLen]
+}
@@ -0,0 +1,223 @@
+package feedbin
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// Known answers from RFC 7914 section 11 and the RFC 6070 inputs
+	// computed with SHA-256
+	tests := []struct {
+		password   string
+		salt       string
+		iterations int
+		keyLen     int
+		want       string
+	}{
+		{"password", "salt", 1, 32, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 2, 32, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, 32, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 40, "348c89dbcbd32b2f32d814b8116e84cf2b17347ebc1800181c4e2a1fb8dd53e1c635518c7dac47e9"},
+		{"passwd", "salt", 1, 64, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+	}
+
+	for _, tt := range tests {
+		got := hex.EncodeToString(pbkdf2SHA256([]byte(tt.password), []byte(tt.salt), tt.iterations, tt.keyLen))
+		if got != tt.want {
+			t.Errorf("pbkdf2SHA256(%q, %q, %d, %d) = %s, want %s", tt.password, tt.salt, tt.iterations, tt.keyLen, got, tt.want)
+		}
+	}
+}
+
+func TestVaultCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedbin.vault")
+	creds := &Credentials{Username: "user@example.com", Password: "s3cret"}
+
+	if err := WriteVault(path, []byte("passphrase"), creds); err != nil {
+		t.Fatalf("WriteVault returned error: %v", err)
+	}
+
+	got, err := VaultCredentials{
+		Path:       path,
+		Passphrase: func() ([]byte, error) { return []byte("passphrase"), nil },
+	}.Credentials()
+	if err != nil {
+		t.Fatalf("Credentials returned error: %v", err)
+	}
+	if *got != *creds {
+		t.Errorf("Credentials = %+v, want %+v", got, creds)
+	}
+
+	_, err = VaultCredentials{
+		Path:       path,
+		Passphrase: func() ([]byte, error) { return []byte("wrong"), nil },
+	}.Credentials()
+	if err != ErrVaultPassphrase {
+		t.Errorf("Credentials with the wrong passphrase returned %v, want %v", err, ErrVaultPassphrase)
+	}
+}
+
+func TestParseNetrc(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		machine string
+		want    *Credentials
+	}{
+		{
+			name:    "matching machine",
+			data:    "machine example.com login other password x\nmachine api.feedbin.com\n  login user\n  password pass\n",
+			machine: "api.feedbin.com",
+			want:    &Credentials{Username: "user", Password: "pass"},
+		},
+		{
+			name:    "single line with account",
+			data:    "machine api.feedbin.com login user account acct password pass",
+			machine: "api.feedbin.com",
+			want:    &Credentials{Username: "user", Password: "pass"},
+		},
+		{
+			name:    "default fallback",
+			data:    "machine example.com login other password x\ndefault login user password pass\n",
+			machine: "api.feedbin.com",
+			want:    &Credentials{Username: "user", Password: "pass"},
+		},
+		{
+			name:    "first match wins over default",
+			data:    "default login fallback password x\nmachine api.feedbin.com login user password pass\nmachine api.feedbin.com login second password y\n",
+			machine: "api.feedbin.com",
+			want:    &Credentials{Username: "user", Password: "pass"},
+		},
+		{
+			name:    "comments and macros are skipped",
+			data:    "# machine api.feedbin.com login commented password x\nmacdef init\nmachine api.feedbin.com login macro password x\n\nmachine api.feedbin.com login user password pass # trailing\n",
+			machine: "api.feedbin.com",
+			want:    &Credentials{Username: "user", Password: "pass"},
+		},
+		{
+			name:    "no match",
+			data:    "machine example.com login other password x\n",
+			machine: "api.feedbin.com",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		got := parseNetrc([]byte(tt.data), tt.machine)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: parseNetrc = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNetrcCredentialsMissingPassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".netrc")
+	if err := os.WriteFile(path, []byte("machine api.feedbin.com login user\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (NetrcCredentials{Path: path}).Credentials(); err == nil {
+		t.Error("Expected an error for an entry without a password")
+	}
+}
+
+// rotatingCredentials returns the next password each time it is resolved
+type rotatingCredentials struct {
+	mu        sync.Mutex
+	passwords []string
+	calls     int
+}
+
+func (p *rotatingCredentials) Credentials() (*Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	password := p.passwords[len(p.passwords)-1]
+	if p.calls < len(p.passwords) {
+		password = p.passwords[p.calls]
+	}
+	p.calls++
+
+	return &Credentials{Username: "user", Password: password}, nil
+}
+
+func newCredentialsTestClient(t *testing.T, provider CredentialsProvider) (*Client, *[]string) {
+	var mu sync.Mutex
+	var seen []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, password, _ := r.BasicAuth()
+
+		mu.Lock()
+		seen = append(seen, password)
+		mu.Unlock()
+
+		if password != "new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClientWithProvider(provider)
+	client.BaseURL, _ = url.Parse(server.URL + "/v2/")
+	return client, &seen
+}
+
+func TestClientRetriesWithRotatedCredentials(t *testing.T) {
+	provider := &rotatingCredentials{passwords: []string{"old", "new"}}
+	client, seen := newCredentialsTestClient(t, provider)
+
+	if _, _, err := client.Subscriptions.List(nil); err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+
+	if len(*seen) != 2 || (*seen)[0] != "old" || (*seen)[1] != "new" {
+		t.Errorf("Server saw passwords %v, want [old new]", *seen)
+	}
+}
+
+func TestClientFailsWhenCredentialsAreUnchanged(t *testing.T) {
+	client, seen := newCredentialsTestClient(t, StaticCredentials{Username: "user", Password: "old"})
+
+	_, _, err := client.Subscriptions.List(nil)
+	if errResp, ok := err.(*ErrorResponse); !ok || errResp.Response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("List returned %v, want a 401 error", err)
+	}
+	if len(*seen) != 1 {
+		t.Errorf("Server saw %d requests, want no retry with the same credentials", len(*seen))
+	}
+}
+
+func TestClientResolvesCredentialsPerRequest(t *testing.T) {
+	provider := &rotatingCredentials{passwords: []string{"new"}}
+	client, _ := newCredentialsTestClient(t, provider)
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := client.Subscriptions.List(nil); err != nil {
+			t.Fatalf("List returned error: %v", err)
+		}
+	}
+	if provider.calls != 3 {
+		t.Errorf("Provider resolved %d times, want once per request", provider.calls)
+	}
+
+	provider.calls = 0
+	client.CredentialsTTL = time.Hour
+	client.SetCredentialsProvider(provider)
+	for i := 0; i < 3; i++ {
+		client.Subscriptions.List(nil)
+	}
+	if provider.calls != 1 {
+		t.Errorf("Provider resolved %d times with a TTL, want 1", provider.calls)
+	}
+}
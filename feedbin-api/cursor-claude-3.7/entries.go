@@ -128,7 +128,9 @@ func (s *EntriesService) GetExtractedContent(extractedContentURL string) (*Extra
 	}
 
 	// Add authentication
-	req.SetBasicAuth(s.client.username, s.client.password)
+	if err := s.client.setBasicAuth(req); err != nil {
+		return nil, nil, err
+	}
 	req.Header.Set("User-Agent", s.client.UserAgent)
 
 	content := new(ExtractedContent)
@@ -0,0 +1,55 @@
+package feedbin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractSignedURL(t *testing.T) {
+	client := NewClient("user", "pass")
+	client.Extract.SetCredentials("feedbin", "secret")
+
+	got, err := client.Extract.SignedURL("https://example.com/article")
+	if err != nil {
+		t.Fatalf("SignedURL returned error: %v", err)
+	}
+
+	want := "https://extract.feedbin.com/parser/feedbin/"
+	if !strings.HasPrefix(got, want) || !strings.Contains(got, "?base64_url=aHR0cHM6Ly9leGFtcGxlLmNvbS9hcnRpY2xl") {
+		t.Errorf("SignedURL = %s, want prefix %s and the base64 page URL", got, want)
+	}
+}
+
+func TestExtractSignedURLEscapesUsernameOnce(t *testing.T) {
+	client := NewClient("user", "pass")
+
+	tests := []struct {
+		username string
+		segment  string
+	}{
+		{"a b", "/parser/a%20b/"},
+		{"a/b", "/parser/a%2Fb/"},
+		{"a%b", "/parser/a%25b/"},
+	}
+
+	for _, tt := range tests {
+		client.Extract.SetCredentials(tt.username, "secret")
+
+		got, err := client.Extract.SignedURL("https://example.com/article")
+		if err != nil {
+			t.Fatalf("SignedURL returned error: %v", err)
+		}
+
+		if !strings.Contains(got, tt.segment) {
+			t.Errorf("SignedURL for username %q = %s, want path segment %s", tt.username, got, tt.segment)
+		}
+	}
+}
+
+func TestExtractSignedURLRequiresCredentials(t *testing.T) {
+	client := NewClient("user", "pass")
+
+	if _, err := client.Extract.SignedURL("https://example.com/article"); err != ErrExtractCredentials {
+		t.Errorf("SignedURL error = %v, want %v", err, ErrExtractCredentials)
+	}
+}
@@ -0,0 +1,289 @@
+package feedbin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Journal actions
+const (
+	JournalMarkRead   = "read"
+	JournalMarkUnread = "unread"
+	JournalStar       = "star"
+	JournalUnstar     = "unstar"
+)
+
+// ErrNoJournal is returned by the undo methods when the client has no journal
+var ErrNoJournal = errors.New("client has no mutation journal")
+
+// JournalRecord is one confirmed unread or starred mutation
+type JournalRecord struct {
+	ID     int    `json:"id"`
+	Action string `json:"action"`
+	Label  string `json:"label,omitempty"`
+
+	// EntryIDs are the entries the server confirmed changing
+	EntryIDs []int `json:"entry_ids"`
+
+	Time time.Time `json:"time"`
+
+	// UndoOf is the record this mutation reverted, if any
+	UndoOf int `json:"undo_of,omitempty"`
+
+	// UndoneBy is the record that reverted this one. It is derived from
+	// later records when the journal is read.
+	UndoneBy int `json:"-"`
+}
+
+// Journal is an append-only log of unread and starred mutations, stored as
+// one JSON record per line
+type Journal struct {
+	Path string
+
+	mu     sync.Mutex
+	nextID int
+}
+
+// OpenJournal opens the journal at path, creating it on the first record
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{Path: path, nextID: 1}
+
+	records, err := j.Records()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) > 0 {
+		j.nextID = records[len(records)-1].ID + 1
+	}
+
+	return j, nil
+}
+
+// Records returns every record, oldest first
+func (j *Journal) Records() ([]*JournalRecord, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.read()
+}
+
+// read parses the journal file; the caller holds mu
+func (j *Journal) read() ([]*JournalRecord, error) {
+	f, err := os.Open(j.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*JournalRecord
+	byID := make(map[int]*JournalRecord)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		record := new(JournalRecord)
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, fmt.Errorf("error reading journal %s line %d: %w", j.Path, line, err)
+		}
+
+		if undone, ok := byID[record.UndoOf]; ok {
+			undone.UndoneBy = record.ID
+		}
+
+		byID[record.ID] = record
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Find returns the record with id
+func (j *Journal) Find(id int) (*JournalRecord, error) {
+	records, err := j.Records()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		if record.ID == id {
+			return record, nil
+		}
+	}
+
+	return nil, fmt.Errorf("journal record %d not found", id)
+}
+
+// append writes a record to the end of the journal
+func (j *Journal) append(action, label string, undoOf int, entryIDs []int) (*JournalRecord, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	record := &JournalRecord{
+		ID:       j.nextID,
+		Action:   action,
+		Label:    label,
+		EntryIDs: entryIDs,
+		Time:     time.Now().UTC(),
+		UndoOf:   undoOf,
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(j.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	j.nextID++
+	return record, nil
+}
+
+// journal records a confirmed mutation when the client has a journal.
+// Mutations that changed nothing are skipped.
+func (c *Client) journal(action, label string, entryIDs []int) error {
+	if c.Journal == nil || len(entryIDs) == 0 {
+		return nil
+	}
+
+	if _, err := c.Journal.append(action, label, 0, entryIDs); err != nil {
+		return fmt.Errorf("mutation succeeded but could not be journaled: %w", err)
+	}
+
+	return nil
+}
+
+// Undo reverts the n most recent mutations that have not been undone yet,
+// newest first, and returns the records of the reverting mutations
+func (c *Client) Undo(n int) ([]*JournalRecord, error) {
+	return c.undo(func(record *JournalRecord) bool { return true }, n)
+}
+
+// UndoLabel reverts every mutation with label that has not been undone
+// yet, newest first
+func (c *Client) UndoLabel(label string) ([]*JournalRecord, error) {
+	return c.undo(func(record *JournalRecord) bool { return record.Label == label }, -1)
+}
+
+// UndoRecord reverts a single mutation
+func (c *Client) UndoRecord(id int) ([]*JournalRecord, error) {
+	return c.undo(func(record *JournalRecord) bool { return record.ID == id }, 1)
+}
+
+// undo reverts up to limit matching records, or all of them when limit is
+// negative, and returns their undo records. Undo records themselves are
+// never reverted.
+func (c *Client) undo(match func(*JournalRecord) bool, limit int) ([]*JournalRecord, error) {
+	if c.Journal == nil {
+		return nil, ErrNoJournal
+	}
+
+	records, err := c.Journal.Records()
+	if err != nil {
+		return nil, err
+	}
+
+	var undone []*JournalRecord
+	for i := len(records) - 1; i >= 0 && limit != 0; i-- {
+		record := records[i]
+		if record.UndoOf != 0 || record.UndoneBy != 0 || !match(record) {
+			continue
+		}
+
+		undoRecord, err := c.revert(record)
+		if undoRecord != nil {
+			undone = append(undone, undoRecord)
+		}
+		if err != nil {
+			return undone, fmt.Errorf("error undoing journal record %d: %w", record.ID, err)
+		}
+
+		limit--
+	}
+
+	return undone, nil
+}
+
+// revert applies the inverse of a record in batches of 1000 IDs and
+// journals the IDs the server confirmed as one undo record. When a batch
+// fails, the batches that succeeded are still journaled.
+func (c *Client) revert(record *JournalRecord) (*JournalRecord, error) {
+	unread := &UnreadService{client: c, skipJournal: true}
+	starred := &StarredService{client: c, skipJournal: true}
+
+	var inverse func([]int) ([]int, *http.Response, error)
+	var action string
+	switch record.Action {
+	case JournalMarkRead:
+		inverse, action = unread.MarkAsUnread, JournalMarkUnread
+	case JournalMarkUnread:
+		inverse, action = unread.MarkAsRead, JournalMarkRead
+	case JournalStar:
+		inverse, action = starred.Unstar, JournalUnstar
+	case JournalUnstar:
+		inverse, action = starred.Star, JournalStar
+	default:
+		return nil, fmt.Errorf("unknown journal action %q", record.Action)
+	}
+
+	confirmed := []int{}
+	var revertErr error
+	for start := 0; start < len(record.EntryIDs); start += 1000 {
+		end := start + 1000
+		if end > len(record.EntryIDs) {
+			end = len(record.EntryIDs)
+		}
+
+		ids, _, err := inverse(record.EntryIDs[start:end])
+		if err != nil {
+			revertErr = err
+			break
+		}
+		confirmed = append(confirmed, ids...)
+	}
+
+	if revertErr != nil && len(confirmed) == 0 {
+		return nil, revertErr
+	}
+
+	undoRecord, err := c.Journal.append(action, record.Label, record.ID, confirmed)
+	if err != nil {
+		return nil, fmt.Errorf("undo succeeded but could not be journaled: %w", err)
+	}
+
+	return undoRecord, revertErr
+}
@@ -0,0 +1,183 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// fakeEntryState serves the unread and starred mutation endpoints
+type fakeEntryState struct {
+	mu      sync.Mutex
+	unread  map[int]bool
+	starred map[int]bool
+	batches []int
+
+	// failBatch makes the nth mutation request (counting from 1) fail
+	failBatch int
+}
+
+func newJournalTestClient(t *testing.T, state *fakeEntryState) *Client {
+	server := httptest.NewServer(state)
+	t.Cleanup(server.Close)
+
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	if err != nil {
+		t.Fatalf("OpenJournal returned error: %v", err)
+	}
+
+	client := NewClient("user", "pass")
+	client.BaseURL, _ = url.Parse(server.URL + "/v2/")
+	client.Journal = journal
+	return client
+}
+
+func (s *fakeEntryState) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var body struct {
+		UnreadEntries  []int `json:"unread_entries"`
+		StarredEntries []int `json:"starred_entries"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	set, ids := s.unread, body.UnreadEntries
+	if r.URL.Path == "/v2/starred_entries.json" {
+		set, ids = s.starred, body.StarredEntries
+	}
+
+	s.batches = append(s.batches, len(ids))
+	if len(s.batches) == s.failBatch {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	changed := []int{}
+	for _, id := range ids {
+		if r.Method == http.MethodPost && !set[id] {
+			set[id] = true
+			changed = append(changed, id)
+		} else if r.Method == http.MethodDelete && set[id] {
+			delete(set, id)
+			changed = append(changed, id)
+		}
+	}
+
+	json.NewEncoder(w).Encode(changed)
+}
+
+func sortedKeys(set map[int]bool) []int {
+	keys := []int{}
+	for id := range set {
+		keys = append(keys, id)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func TestUndoRestoresMarkRead(t *testing.T) {
+	state := &fakeEntryState{unread: map[int]bool{1: true, 2: true, 3: true}, starred: map[int]bool{}}
+	client := newJournalTestClient(t, state)
+
+	if _, _, err := client.Unread.MarkAsRead([]int{1, 2, 9}); err != nil {
+		t.Fatalf("MarkAsRead returned error: %v", err)
+	}
+	if got := sortedKeys(state.unread); !reflect.DeepEqual(got, []int{3}) {
+		t.Fatalf("Unread after MarkAsRead = %v, want [3]", got)
+	}
+
+	undone, err := client.Undo(1)
+	if err != nil {
+		t.Fatalf("Undo returned error: %v", err)
+	}
+
+	if got := sortedKeys(state.unread); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("Unread after Undo = %v, want [1 2 3]", got)
+	}
+	if len(undone) != 1 || undone[0].Action != JournalMarkUnread || undone[0].UndoOf != 1 || !reflect.DeepEqual(undone[0].EntryIDs, []int{1, 2}) {
+		t.Errorf("Undo records = %+v, want one unread record for entries 1 and 2", undone)
+	}
+
+	// The mutation is now undone, and undo records are never reverted
+	record, _ := client.Journal.Find(1)
+	if record.UndoneBy != 2 {
+		t.Errorf("UndoneBy = %d, want 2", record.UndoneBy)
+	}
+	if undone, _ := client.Undo(1); len(undone) != 0 {
+		t.Errorf("Second Undo reverted %+v, want nothing", undone)
+	}
+}
+
+func TestUndoLabelRevertsStarredMutations(t *testing.T) {
+	state := &fakeEntryState{unread: map[int]bool{}, starred: map[int]bool{6: true}}
+	client := newJournalTestClient(t, state)
+
+	client.Starred.WithLabel("triage").Star([]int{5})
+	client.Starred.WithLabel("other").Unstar([]int{6})
+	client.Starred.WithLabel("triage").Star([]int{7})
+
+	undone, err := client.UndoLabel("triage")
+	if err != nil {
+		t.Fatalf("UndoLabel returned error: %v", err)
+	}
+
+	if len(undone) != 2 || undone[0].UndoOf != 3 || undone[1].UndoOf != 1 {
+		t.Errorf("UndoLabel records = %+v, want undos of records 3 and 1", undone)
+	}
+	if got := sortedKeys(state.starred); len(got) != 0 {
+		t.Errorf("Starred after UndoLabel = %v, want none", got)
+	}
+}
+
+func TestUndoRecordBatchesByThousand(t *testing.T) {
+	ids := make([]int, 2500)
+	state := &fakeEntryState{unread: map[int]bool{}, starred: map[int]bool{}}
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	client := newJournalTestClient(t, state)
+
+	// Records written by other tools may hold more IDs than one request
+	if _, err := client.Journal.append(JournalMarkRead, "bulk", 0, ids); err != nil {
+		t.Fatalf("append returned error: %v", err)
+	}
+
+	undone, err := client.UndoRecord(1)
+	if err != nil {
+		t.Fatalf("UndoRecord returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(state.batches, []int{1000, 1000, 500}) {
+		t.Errorf("Batches = %v, want [1000 1000 500]", state.batches)
+	}
+	if len(state.unread) != 2500 || len(undone) != 1 || len(undone[0].EntryIDs) != 2500 {
+		t.Errorf("Undo restored %d entries, want 2500", len(state.unread))
+	}
+}
+
+func TestUndoRecordJournalsPartialRevert(t *testing.T) {
+	ids := make([]int, 1500)
+	state := &fakeEntryState{unread: map[int]bool{}, starred: map[int]bool{}, failBatch: 2}
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	client := newJournalTestClient(t, state)
+	client.Journal.append(JournalMarkRead, "", 0, ids)
+
+	undone, err := client.UndoRecord(1)
+	if err == nil {
+		t.Fatal("Expected an error for the failed batch")
+	}
+
+	// The first batch succeeded and is journaled so it is not lost
+	if len(undone) != 1 || len(undone[0].EntryIDs) != 1000 || len(state.unread) != 1000 {
+		t.Errorf("Undo records = %d, want the 1000 confirmed entries journaled", len(undone))
+	}
+}
@@ -74,7 +74,10 @@ type PageOptions struct {
 	Since   string `url:"since,omitempty"`
 }
 
-// AddQueryParams adds query parameters to the URL
+// AddQueryParams adds query parameters to the URL. It encodes *PageOptions,
+// *EntryOptions and *SubscriptionOptions; other types leave the URL as is.
+// Entry and subscription options are encoded together with their embedded
+// PageOptions.
 func AddQueryParams(baseURL string, opts interface{}) (string, error) {
 	if opts == nil {
 		return baseURL, nil
@@ -87,82 +90,83 @@ func AddQueryParams(baseURL string, opts interface{}) (string, error) {
 
 	params := u.Query()
 
-	// We'll use reflection to extract query parameters from the struct
 	switch v := opts.(type) {
 	case *PageOptions:
-		if v.Page != nil {
-			params.Set("page", strconv.Itoa(*v.Page))
+		if v != nil {
+			handlePageOptions(v, params)
 		}
-		if v.PerPage != nil {
-			params.Set("per_page", strconv.Itoa(*v.PerPage))
+	case *EntryOptions:
+		if v != nil {
+			handlePageOptions(&v.PageOptions, params)
+			handleEntryOptions(v, params)
 		}
-		if v.Since != "" {
-			params.Set("since", v.Since)
+	case *SubscriptionOptions:
+		if v != nil {
+			handlePageOptions(&v.PageOptions, params)
+			handleSubscriptionOptions(v, params)
 		}
 	}
 
-	// Handle additional option types
-	handleEntryOptions(opts, params)
-	handleSubscriptionOptions(opts, params)
-
 	u.RawQuery = params.Encode()
 	return u.String(), nil
 }
 
+// handlePageOptions adds pagination query parameters
+func handlePageOptions(pageOpts *PageOptions, params url.Values) {
+	if pageOpts.Page != nil {
+		params.Set("page", strconv.Itoa(*pageOpts.Page))
+	}
+	if pageOpts.PerPage != nil {
+		params.Set("per_page", strconv.Itoa(*pageOpts.PerPage))
+	}
+	if pageOpts.Since != "" {
+		params.Set("since", pageOpts.Since)
+	}
+}
+
 // handleEntryOptions adds entry-specific query parameters
-func handleEntryOptions(opts interface{}, params url.Values) {
-	type EntryOptions struct {
-		IDs                []int  `url:"ids,omitempty"`
-		Read               *bool  `url:"read,omitempty"`
-		Starred            *bool  `url:"starred,omitempty"`
-		Mode               string `url:"mode,omitempty"`
-		IncludeOriginal    *bool  `url:"include_original,omitempty"`
-		IncludeEnclosure   *bool  `url:"include_enclosure,omitempty"`
-		IncludeContentDiff *bool  `url:"include_content_diff,omitempty"`
-	}
-
-	if entryOpts, ok := opts.(*EntryOptions); ok {
-		if len(entryOpts.IDs) > 0 {
-			idStrs := make([]string, len(entryOpts.IDs))
-			for i, id := range entryOpts.IDs {
-				idStrs[i] = strconv.Itoa(id)
-			}
-			params.Set("ids", strings.Join(idStrs, ","))
-		}
-		if entryOpts.Read != nil {
-			params.Set("read", strconv.FormatBool(*entryOpts.Read))
-		}
-		if entryOpts.Starred != nil {
-			params.Set("starred", strconv.FormatBool(*entryOpts.Starred))
-		}
-		if entryOpts.Mode != "" {
-			params.Set("mode", entryOpts.Mode)
-		}
-		if entryOpts.IncludeOriginal != nil {
-			params.Set("include_original", strconv.FormatBool(*entryOpts.IncludeOriginal))
-		}
-		if entryOpts.IncludeEnclosure != nil {
-			params.Set("include_enclosure", strconv.FormatBool(*entryOpts.IncludeEnclosure))
-		}
-		if entryOpts.IncludeContentDiff != nil {
-			params.Set("include_content_diff", strconv.FormatBool(*entryOpts.IncludeContentDiff))
+func handleEntryOptions(entryOpts *EntryOptions, params url.Values) {
+	if entryOpts == nil {
+		return
+	}
+
+	if len(entryOpts.IDs) > 0 {
+		idStrs := make([]string, len(entryOpts.IDs))
+		for i, id := range entryOpts.IDs {
+			idStrs[i] = strconv.Itoa(id)
 		}
+		params.Set("ids", strings.Join(idStrs, ","))
+	}
+	if entryOpts.Read != nil {
+		params.Set("read", strconv.FormatBool(*entryOpts.Read))
+	}
+	if entryOpts.Starred != nil {
+		params.Set("starred", strconv.FormatBool(*entryOpts.Starred))
+	}
+	if entryOpts.Mode != "" {
+		params.Set("mode", entryOpts.Mode)
+	}
+	if entryOpts.IncludeOriginal != nil {
+		params.Set("include_original", strconv.FormatBool(*entryOpts.IncludeOriginal))
+	}
+	if entryOpts.IncludeEnclosure != nil {
+		params.Set("include_enclosure", strconv.FormatBool(*entryOpts.IncludeEnclosure))
+	}
+	if entryOpts.IncludeContentDiff != nil {
+		params.Set("include_content_diff", strconv.FormatBool(*entryOpts.IncludeContentDiff))
 	}
 }
 
 // handleSubscriptionOptions adds subscription-specific query parameters
-func handleSubscriptionOptions(opts interface{}, params url.Values) {
-	type SubscriptionOptions struct {
-		Since string `url:"since,omitempty"`
-		Mode  string `url:"mode,omitempty"`
+func handleSubscriptionOptions(subOpts *SubscriptionOptions, params url.Values) {
+	if subOpts == nil {
+		return
 	}
 
-	if subOpts, ok := opts.(*SubscriptionOptions); ok {
-		if subOpts.Since != "" {
-			params.Set("since", subOpts.Since)
-		}
-		if subOpts.Mode != "" {
-			params.Set("mode", subOpts.Mode)
-		}
+	if subOpts.Since != "" {
+		params.Set("since", subOpts.Since)
+	}
+	if subOpts.Mode != "" {
+		params.Set("mode", subOpts.Mode)
 	}
 }
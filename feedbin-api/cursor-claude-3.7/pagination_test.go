@@ -0,0 +1,80 @@
+package feedbin
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestAddQueryParamsEntryOptions(t *testing.T) {
+	opts := &EntryOptions{
+		PageOptions:        PageOptions{Page: Int(2), PerPage: Int(50), Since: "2024-01-02T03:04:05.000000Z"},
+		IDs:                []int{1, 2, 3},
+		Read:               Bool(false),
+		Starred:            Bool(true),
+		Mode:               "extended",
+		IncludeOriginal:    Bool(true),
+		IncludeEnclosure:   Bool(true),
+		IncludeContentDiff: Bool(false),
+	}
+
+	got, err := AddQueryParams("entries.json", opts)
+	if err != nil {
+		t.Fatalf("AddQueryParams returned error: %v", err)
+	}
+
+	u, _ := url.Parse(got)
+	want := url.Values{
+		"page":                 {"2"},
+		"per_page":             {"50"},
+		"since":                {"2024-01-02T03:04:05.000000Z"},
+		"ids":                  {"1,2,3"},
+		"read":                 {"false"},
+		"starred":              {"true"},
+		"mode":                 {"extended"},
+		"include_original":     {"true"},
+		"include_enclosure":    {"true"},
+		"include_content_diff": {"false"},
+	}
+
+	if u.Path != "entries.json" || u.RawQuery != want.Encode() {
+		t.Errorf("AddQueryParams = %s, want entries.json?%s", got, want.Encode())
+	}
+}
+
+func TestAddQueryParamsSubscriptionOptions(t *testing.T) {
+	got, err := AddQueryParams("subscriptions.json", &SubscriptionOptions{
+		Since: "2024-01-02T03:04:05.000000Z",
+		Mode:  "extended",
+	})
+	if err != nil {
+		t.Fatalf("AddQueryParams returned error: %v", err)
+	}
+
+	if want := "subscriptions.json?mode=extended&since=2024-01-02T03%3A04%3A05.000000Z"; got != want {
+		t.Errorf("AddQueryParams = %s, want %s", got, want)
+	}
+}
+
+func TestAddQueryParamsKeepsExistingQuery(t *testing.T) {
+	var nilOpts *EntryOptions
+
+	tests := []struct {
+		opts interface{}
+		want string
+	}{
+		{nil, "entries.json?page=1"},
+		{nilOpts, "entries.json?page=1"},
+		{&PageOptions{PerPage: Int(10)}, "entries.json?page=1&per_page=10"},
+		{struct{}{}, "entries.json?page=1"},
+	}
+
+	for _, tt := range tests {
+		got, err := AddQueryParams("entries.json?page=1", tt.opts)
+		if err != nil {
+			t.Fatalf("AddQueryParams returned error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("AddQueryParams(%#v) = %s, want %s", tt.opts, got, tt.want)
+		}
+	}
+}
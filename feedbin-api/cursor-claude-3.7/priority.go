@@ -0,0 +1,350 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultQuickRead is how soon after an entry is first seen unread it
+	// must be marked read to count as uninteresting
+	DefaultQuickRead = 10 * time.Minute
+	// DefaultLearningRate is the step size of each training update
+	DefaultLearningRate = 0.1
+	// priorityRegularization shrinks weights slightly on every update so
+	// old preferences fade
+	priorityRegularization = 0.001
+	// priorityReasons is the number of features explaining a score
+	priorityReasons = 3
+)
+
+// PriorityInbox is a logistic regression model that predicts interest in
+// entries. Starred entries are positive examples and entries marked read
+// soon after they were first seen unread, without a star, are negative
+// examples. The model and the training state are saved to Path.
+type PriorityInbox struct {
+	Path string `json:"-"`
+
+	// QuickRead is the longest time between first seeing an entry unread
+	// and it being read for it to count as a negative example
+	QuickRead time.Duration `json:"quick_read"`
+
+	LearningRate float64            `json:"learning_rate"`
+	Bias         float64            `json:"bias"`
+	Weights      map[string]float64 `json:"weights"`
+	Examples     int                `json:"examples"`
+
+	// Labels are the entries trained on, true for positive examples
+	Labels map[int]bool `json:"labels"`
+
+	// Unread holds when each unread entry was first seen
+	Unread map[int]time.Time `json:"unread"`
+}
+
+// PriorityReason is a feature's contribution to a score
+type PriorityReason struct {
+	Feature string
+	Weight  float64
+}
+
+// String formats the reason, e.g. "feed:42 (+1.20)"
+func (r PriorityReason) String() string {
+	return fmt.Sprintf("%s (%+.2f)", r.Feature, r.Weight)
+}
+
+// RankedEntry is an entry with its predicted interest between 0 and 1 and
+// the features that contributed most to it
+type RankedEntry struct {
+	Entry   *Entry
+	Score   float64
+	Reasons []PriorityReason
+}
+
+// PriorityTraining summarises a training run
+type PriorityTraining struct {
+	Positives int
+	Negatives int
+}
+
+// LoadPriorityInbox loads the model saved at path or returns an untrained one
+func LoadPriorityInbox(path string) (*PriorityInbox, error) {
+	p := &PriorityInbox{
+		Path:         path,
+		QuickRead:    DefaultQuickRead,
+		LearningRate: DefaultLearningRate,
+		Weights:      make(map[string]float64),
+		Labels:       make(map[int]bool),
+		Unread:       make(map[int]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("error reading priority model %s: %w", path, err)
+	}
+
+	if p.Weights == nil {
+		p.Weights = make(map[string]float64)
+	}
+	if p.Labels == nil {
+		p.Labels = make(map[int]bool)
+	}
+	if p.Unread == nil {
+		p.Unread = make(map[int]time.Time)
+	}
+
+	return p, nil
+}
+
+// Save writes the model to Path
+func (p *PriorityInbox) Save() error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	tmp := p.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, p.Path)
+}
+
+// Train updates the model with one example
+func (p *PriorityInbox) Train(entry *Entry, positive bool) {
+	features := priorityFeatures(entry)
+
+	target := 0.0
+	if positive {
+		target = 1.0
+	}
+
+	gradient := target - p.predict(features)
+	rate := p.LearningRate
+	if rate <= 0 {
+		rate = DefaultLearningRate
+	}
+
+	p.Bias += rate * gradient
+	for _, feature := range features {
+		weight := p.Weights[feature]
+		weight += rate * (gradient - priorityRegularization*weight)
+		p.Weights[feature] = weight
+	}
+
+	p.Labels[entry.ID] = positive
+	p.Examples++
+}
+
+// Score returns the predicted interest in an entry
+func (p *PriorityInbox) Score(entry *Entry) float64 {
+	return p.predict(priorityFeatures(entry))
+}
+
+// Rank orders entries by predicted interest, highest first
+func (p *PriorityInbox) Rank(entries []*Entry) []*RankedEntry {
+	ranked := make([]*RankedEntry, len(entries))
+	for i, entry := range entries {
+		features := priorityFeatures(entry)
+
+		reasons := make([]PriorityReason, 0, len(features))
+		for _, feature := range features {
+			if weight := p.Weights[feature]; weight != 0 {
+				reasons = append(reasons, PriorityReason{Feature: feature, Weight: weight})
+			}
+		}
+
+		sort.SliceStable(reasons, func(a, b int) bool {
+			return math.Abs(reasons[a].Weight) > math.Abs(reasons[b].Weight)
+		})
+		if len(reasons) > priorityReasons {
+			reasons = reasons[:priorityReasons]
+		}
+
+		ranked[i] = &RankedEntry{
+			Entry:   entry,
+			Score:   p.predict(features),
+			Reasons: reasons,
+		}
+	}
+
+	sort.SliceStable(ranked, func(a, b int) bool {
+		if ranked[a].Score != ranked[b].Score {
+			return ranked[a].Score > ranked[b].Score
+		}
+		return ranked[a].Entry.ID > ranked[b].Entry.ID
+	})
+
+	return ranked
+}
+
+// predict returns the logistic score of a feature set
+func (p *PriorityInbox) predict(features []string) float64 {
+	z := p.Bias
+	for _, feature := range features {
+		z += p.Weights[feature]
+	}
+	return 1 / (1 + math.Exp(-z))
+}
+
+// TrainPriorityInbox trains the model on entries starred or quickly read
+// since the last run and saves it. Each run also records when unread
+// entries were first seen. An entry's read time is only known to be before
+// the run that finds it read, so runs should be more frequent than
+// QuickRead for negative examples to be found.
+func (c *Client) TrainPriorityInbox(p *PriorityInbox) (*PriorityTraining, error) {
+	starred, _, err := c.Starred.List()
+	if err != nil {
+		return nil, err
+	}
+
+	unread, _, err := c.Unread.List()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	isStarred := make(map[int]bool, len(starred))
+	for _, id := range starred {
+		isStarred[id] = true
+	}
+
+	isUnread := make(map[int]bool, len(unread))
+	for _, id := range unread {
+		isUnread[id] = true
+	}
+
+	labels := make(map[int]bool)
+	for _, id := range starred {
+		if positive, ok := p.Labels[id]; !ok || !positive {
+			labels[id] = true
+		}
+	}
+
+	quickRead := p.QuickRead
+	if quickRead <= 0 {
+		quickRead = DefaultQuickRead
+	}
+
+	for id, firstSeen := range p.Unread {
+		if isUnread[id] || isStarred[id] {
+			continue
+		}
+		if _, trained := p.Labels[id]; !trained && now.Sub(firstSeen) <= quickRead {
+			labels[id] = false
+		}
+	}
+
+	ids := make([]int, 0, len(labels))
+	for id := range labels {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	training := &PriorityTraining{}
+	for start := 0; start < len(ids); start += 100 {
+		end := start + 100
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		entries, _, err := c.Entries.GetByIDs(ids[start:end])
+		if err != nil {
+			return training, err
+		}
+
+		for _, entry := range entries {
+			positive := labels[entry.ID]
+			p.Train(entry, positive)
+			if positive {
+				training.Positives++
+			} else {
+				training.Negatives++
+			}
+		}
+	}
+
+	// Keep first-seen times of entries that are still unread
+	seen := make(map[int]time.Time, len(unread))
+	for _, id := range unread {
+		if firstSeen, ok := p.Unread[id]; ok {
+			seen[id] = firstSeen
+		} else {
+			seen[id] = now
+		}
+	}
+	p.Unread = seen
+
+	if err := p.Save(); err != nil {
+		return training, err
+	}
+
+	return training, nil
+}
+
+var priorityTermPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// priorityStopWords are title words too common to carry interest
+var priorityStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "how": true, "in": true,
+	"is": true, "it": true, "of": true, "on": true, "or": true, "that": true,
+	"the": true, "this": true, "to": true, "was": true, "what": true,
+	"when": true, "why": true, "with": true, "you": true, "your": true,
+}
+
+// priorityFeatures returns the features of an entry: its feed, author,
+// title terms and length bucket
+func priorityFeatures(entry *Entry) []string {
+	features := []string{"feed:" + strconv.Itoa(entry.FeedID)}
+
+	if entry.Author != nil && strings.TrimSpace(*entry.Author) != "" {
+		features = append(features, "author:"+strings.ToLower(strings.TrimSpace(*entry.Author)))
+	}
+
+	if entry.Title != nil {
+		seen := make(map[string]bool)
+		for _, term := range priorityTermPattern.FindAllString(strings.ToLower(*entry.Title), -1) {
+			if len(term) < 2 || priorityStopWords[term] || seen[term] {
+				continue
+			}
+			seen[term] = true
+			features = append(features, "title:"+term)
+		}
+	}
+
+	text := ""
+	if entry.Content != nil {
+		text = *entry.Content
+	} else if entry.Summary != nil {
+		text = *entry.Summary
+	}
+
+	words := len(strings.Fields(htmlTagPattern.ReplaceAllString(text, " ")))
+	switch {
+	case words < 150:
+		features = append(features, "length:short")
+	case words < 1000:
+		features = append(features, "length:medium")
+	default:
+		features = append(features, "length:long")
+	}
+
+	return features
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
@@ -0,0 +1,113 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPriorityTestClient serves the starred and unread IDs and one entry per
+// ID, whose feed is the ID itself
+func newPriorityTestClient(t *testing.T, starred, unread []int) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/starred_entries.json":
+			json.NewEncoder(w).Encode(starred)
+		case "/v2/unread_entries.json":
+			json.NewEncoder(w).Encode(unread)
+		case "/v2/entries.json":
+			var entries []*Entry
+			for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+				n, _ := strconv.Atoi(id)
+				entries = append(entries, &Entry{ID: n, FeedID: n, Title: String("Entry " + id)})
+			}
+			json.NewEncoder(w).Encode(entries)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient("user", "pass")
+	client.BaseURL, _ = url.Parse(server.URL + "/v2/")
+	return client
+}
+
+func TestTrainPriorityInboxLabels(t *testing.T) {
+	client := newPriorityTestClient(t, []int{5}, []int{3, 4, 6})
+
+	p, err := LoadPriorityInbox(filepath.Join(t.TempDir(), "priority.json"))
+	if err != nil {
+		t.Fatalf("LoadPriorityInbox returned error: %v", err)
+	}
+
+	now := time.Now().UTC()
+	p.Unread = map[int]time.Time{
+		1: now.Add(-2 * time.Minute),    // read quickly
+		2: now.Add(-time.Hour),          // read, but not quickly
+		3: now.Add(-8 * 24 * time.Hour), // unread for a long time
+		4: now.Add(-24 * time.Hour),     // unread, but not for long
+		5: now.Add(-2 * time.Minute),    // read and starred
+	}
+
+	training, err := client.TrainPriorityInbox(p)
+	if err != nil {
+		t.Fatalf("TrainPriorityInbox returned error: %v", err)
+	}
+
+	if training.Positives != 1 || training.Negatives != 1 {
+		t.Errorf("Training = %+v, want 1 positive and 1 negative", training)
+	}
+	if want := map[int]bool{1: false, 5: true}; !reflect.DeepEqual(p.Labels, want) {
+		t.Errorf("Labels = %v, want %v", p.Labels, want)
+	}
+
+	// Entries still unread keep when they were first seen
+	if len(p.Unread) != 3 || !p.Unread[3].Equal(now.Add(-8*24*time.Hour)) || p.Unread[6].IsZero() {
+		t.Errorf("Unread = %v, want entries 3, 4 and 6", p.Unread)
+	}
+
+	// The model was saved, and trained entries are not trained on again
+	reloaded, err := LoadPriorityInbox(p.Path)
+	if err != nil {
+		t.Fatalf("LoadPriorityInbox returned error: %v", err)
+	}
+	training, err = client.TrainPriorityInbox(reloaded)
+	if err != nil {
+		t.Fatalf("TrainPriorityInbox returned error: %v", err)
+	}
+	if training.Positives != 0 || training.Negatives != 0 || reloaded.Examples != 2 {
+		t.Errorf("Second training = %+v after %d examples, want nothing new", training, reloaded.Examples)
+	}
+}
+
+func TestPriorityInboxRank(t *testing.T) {
+	p, _ := LoadPriorityInbox(filepath.Join(t.TempDir(), "priority.json"))
+
+	liked := &Entry{ID: 1, FeedID: 1, Title: String("Go generics deep dive"), Author: String("Rob")}
+	ignored := &Entry{ID: 2, FeedID: 2, Title: String("Celebrity gossip roundup")}
+	for i := 0; i < 20; i++ {
+		p.Train(liked, true)
+		p.Train(ignored, false)
+	}
+
+	ranked := p.Rank([]*Entry{
+		{ID: 3, FeedID: 2, Title: String("More celebrity gossip")},
+		{ID: 4, FeedID: 1, Title: String("Go generics in practice")},
+	})
+
+	if ranked[0].Entry.ID != 4 || ranked[0].Score <= 0.5 || ranked[1].Score >= 0.5 {
+		t.Fatalf("Rank = %v (%.2f), %v (%.2f), want entry 4 first", ranked[0].Entry.ID, ranked[0].Score, ranked[1].Entry.ID, ranked[1].Score)
+	}
+
+	if len(ranked[0].Reasons) != priorityReasons || ranked[0].Reasons[0].Weight <= 0 {
+		t.Errorf("Reasons = %v, want the %d strongest positive features", ranked[0].Reasons, priorityReasons)
+	}
+}
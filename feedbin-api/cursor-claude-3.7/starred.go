@@ -8,6 +8,18 @@ import (
 // endpoints of the Feedbin API
 type StarredService struct {
 	client *Client
+
+	// label is recorded in the client's journal with each mutation
+	label string
+
+	// skipJournal is set for mutations the journal records itself
+	skipJournal bool
+}
+
+// WithLabel returns a copy of the service whose mutations are journaled
+// with label
+func (s *StarredService) WithLabel(label string) *StarredService {
+	return &StarredService{client: s.client, label: label}
 }
 
 // StarredEntriesRequest represents a request to star or unstar entries
@@ -54,6 +66,10 @@ func (s *StarredService) Star(entryIDs []int) ([]int, *http.Response, error) {
 		return nil, resp, err
 	}
 
+	if err := s.journal(JournalStar, markedIDs); err != nil {
+		return markedIDs, resp, err
+	}
+
 	return markedIDs, resp, nil
 }
 
@@ -79,6 +95,10 @@ func (s *StarredService) Unstar(entryIDs []int) ([]int, *http.Response, error) {
 		return nil, resp, err
 	}
 
+	if err := s.journal(JournalUnstar, markedIDs); err != nil {
+		return markedIDs, resp, err
+	}
+
 	return markedIDs, resp, nil
 }
 
@@ -105,5 +125,18 @@ func (s *StarredService) UnstarAlternative(entryIDs []int) ([]int, *http.Respons
 		return nil, resp, err
 	}
 
+	if err := s.journal(JournalUnstar, markedIDs); err != nil {
+		return markedIDs, resp, err
+	}
+
 	return markedIDs, resp, nil
 }
+
+// journal records a confirmed mutation in the client's journal
+func (s *StarredService) journal(action string, markedIDs []int) error {
+	if s.skipJournal {
+		return nil
+	}
+
+	return s.client.journal(action, s.label, markedIDs)
+}
@@ -0,0 +1,505 @@
+package feedbin
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TagChangeKind is the kind of a change in a tag plan
+type TagChangeKind string
+
+// Tag change kinds
+const (
+	TagChangeRename TagChangeKind = "rename"
+	TagChangeMerge  TagChangeKind = "merge"
+	TagChangeAdd    TagChangeKind = "add"
+	TagChangeRemove TagChangeKind = "remove"
+)
+
+// TagChange is one step of a tag plan
+type TagChange struct {
+	Kind TagChangeKind
+
+	// From are the tags renamed or merged into To
+	From []string
+	To   string
+
+	// FeedID, FeedTitle and TaggingID identify the tagging of an add or
+	// remove
+	FeedID    int
+	FeedTitle string
+	TaggingID int
+
+	// FeedIDs are the feeds a rename or merge moves to To; for a merge,
+	// only feeds that do not have To yet are tagged
+	FeedIDs []int
+}
+
+// String describes the change
+func (c TagChange) String() string {
+	switch c.Kind {
+	case TagChangeRename:
+		return fmt.Sprintf("rename %q -> %q (%s)", c.From[0], c.To, plural(len(c.FeedIDs), "feed"))
+	case TagChangeMerge:
+		return fmt.Sprintf("merge %s -> %q (%s)", quoteTags(c.From), c.To, plural(len(c.FeedIDs), "feed"))
+	case TagChangeAdd:
+		return fmt.Sprintf("add %q to %s", c.To, feedLabel(c.FeedID, c.FeedTitle))
+	case TagChangeRemove:
+		return fmt.Sprintf("remove %q from %s", c.To, feedLabel(c.FeedID, c.FeedTitle))
+	default:
+		return string(c.Kind)
+	}
+}
+
+// TagPlan is the list of changes that turns the current taggings into the
+// desired ones. Renames and merges come first, then adds, then removes, so
+// a feed never loses every tag while the plan is applied.
+type TagPlan struct {
+	Changes []TagChange
+}
+
+// Empty reports whether the plan has no changes
+func (p *TagPlan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String returns the plan as one change per line followed by a summary
+func (p *TagPlan) String() string {
+	if p.Empty() {
+		return "no tag changes\n"
+	}
+
+	var b strings.Builder
+	counts := make(map[TagChangeKind]int)
+	for _, change := range p.Changes {
+		counts[change.Kind]++
+		b.WriteString(change.String())
+		b.WriteString("\n")
+	}
+
+	var summary []string
+	for _, kind := range []TagChangeKind{TagChangeRename, TagChangeMerge, TagChangeAdd, TagChangeRemove} {
+		if counts[kind] > 0 {
+			summary = append(summary, plural(counts[kind], string(kind)))
+		}
+	}
+
+	fmt.Fprintf(&b, "%s: %s\n", plural(len(p.Changes), "change"), strings.Join(summary, ", "))
+	return b.String()
+}
+
+// TagApplyError is returned when applying a plan stops at a failed change.
+// The changes before Applied have been made; the plan can be rebuilt from
+// a new TagReorg to continue.
+type TagApplyError struct {
+	Applied int
+	Change  TagChange
+	Err     error
+}
+
+func (e *TagApplyError) Error() string {
+	return fmt.Sprintf("tag plan stopped after %d changes at %s: %v", e.Applied, e.Change, e.Err)
+}
+
+func (e *TagApplyError) Unwrap() error {
+	return e.Err
+}
+
+// TagSplit moves the feeds whose title matches Pattern to Tag
+type TagSplit struct {
+	Pattern *regexp.Regexp
+	Tag     string
+}
+
+// TagReorg builds the desired feed to tags mapping starting from the
+// current taggings, then plans and applies the difference
+type TagReorg struct {
+	client *Client
+
+	titles   map[int]string
+	current  map[int]map[string]*Tagging
+	desired  map[int]map[string]bool
+	allFeeds []int
+}
+
+// NewTagReorg loads the current taggings and subscriptions
+func (c *Client) NewTagReorg() (*TagReorg, error) {
+	taggings, _, err := c.Taggings.List()
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions, _, err := c.Subscriptions.List(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &TagReorg{
+		client:  c,
+		titles:  make(map[int]string),
+		current: make(map[int]map[string]*Tagging),
+		desired: make(map[int]map[string]bool),
+	}
+
+	for _, subscription := range subscriptions {
+		r.titles[subscription.FeedID] = subscription.Title
+		r.addFeed(subscription.FeedID)
+	}
+
+	for _, tagging := range taggings {
+		r.addFeed(tagging.FeedID)
+		r.current[tagging.FeedID][tagging.Name] = tagging
+		r.desired[tagging.FeedID][tagging.Name] = true
+	}
+
+	sort.Ints(r.allFeeds)
+	return r, nil
+}
+
+// addFeed makes sure a feed has current and desired tag sets
+func (r *TagReorg) addFeed(feedID int) {
+	if _, ok := r.current[feedID]; ok {
+		return
+	}
+
+	r.current[feedID] = make(map[string]*Tagging)
+	r.desired[feedID] = make(map[string]bool)
+	r.allFeeds = append(r.allFeeds, feedID)
+}
+
+// Current returns the current tags of every feed
+func (r *TagReorg) Current() map[int][]string {
+	mapping := make(map[int][]string)
+	for feedID, tags := range r.current {
+		for name := range tags {
+			mapping[feedID] = append(mapping[feedID], name)
+		}
+		sort.Strings(mapping[feedID])
+	}
+	return mapping
+}
+
+// Desired returns the desired tags of every feed
+func (r *TagReorg) Desired() map[int][]string {
+	mapping := make(map[int][]string)
+	for feedID, tags := range r.desired {
+		for name := range tags {
+			mapping[feedID] = append(mapping[feedID], name)
+		}
+		sort.Strings(mapping[feedID])
+	}
+	return mapping
+}
+
+// SetFeedTags replaces the desired tags of a feed
+func (r *TagReorg) SetFeedTags(feedID int, tags ...string) error {
+	if _, ok := r.current[feedID]; !ok {
+		return fmt.Errorf("feed %d is not subscribed", feedID)
+	}
+
+	r.desired[feedID] = make(map[string]bool)
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			r.desired[feedID][tag] = true
+		}
+	}
+
+	return nil
+}
+
+// SetMapping replaces the desired tags of every feed in mapping. Feeds not
+// in mapping keep their desired tags.
+func (r *TagReorg) SetMapping(mapping map[int][]string) error {
+	for feedID, tags := range mapping {
+		if err := r.SetFeedTags(feedID, tags...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merge moves every feed tagged with one of sources to target
+func (r *TagReorg) Merge(target string, sources ...string) {
+	for _, feedID := range r.allFeeds {
+		tags := r.desired[feedID]
+		for _, source := range sources {
+			if source != target && tags[source] {
+				delete(tags, source)
+				tags[target] = true
+			}
+		}
+	}
+}
+
+// Rename renames a tag
+func (r *TagReorg) Rename(from, to string) {
+	r.Merge(to, from)
+}
+
+// Split moves the feeds tagged with tag to the tag of the first split whose
+// pattern matches the feed title. Feeds that match no pattern keep tag.
+func (r *TagReorg) Split(tag string, splits ...TagSplit) error {
+	for _, split := range splits {
+		if split.Pattern == nil || split.Tag == "" {
+			return fmt.Errorf("split of %q needs a pattern and a tag", tag)
+		}
+	}
+
+	for _, feedID := range r.allFeeds {
+		tags := r.desired[feedID]
+		if !tags[tag] {
+			continue
+		}
+
+		for _, split := range splits {
+			if split.Pattern.MatchString(r.titles[feedID]) {
+				delete(tags, tag)
+				tags[split.Tag] = true
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// Plan diffs the desired mapping against the current taggings. A tag that
+// disappears while a new tag takes exactly its feeds becomes a rename, and
+// tags that disappear into another tag become a merge; everything else is
+// planned as individual adds and removes.
+func (r *TagReorg) Plan() *TagPlan {
+	currentFeeds := tagFeeds(r.allFeeds, func(feedID int) []string { return keys(r.current[feedID]) })
+	desiredFeeds := tagFeeds(r.allFeeds, func(feedID int) []string { return boolKeys(r.desired[feedID]) })
+
+	var vanished []string
+	for tag := range currentFeeds {
+		if _, ok := desiredFeeds[tag]; !ok {
+			vanished = append(vanished, tag)
+		}
+	}
+	sort.Strings(vanished)
+
+	var targets []string
+	for tag := range desiredFeeds {
+		targets = append(targets, tag)
+	}
+	sort.Strings(targets)
+
+	// covered marks feed and tag pairs handled by a rename or merge
+	covered := make(map[string]bool)
+	consumed := make(map[string]bool)
+	plan := &TagPlan{}
+
+	for _, target := range targets {
+		_, exists := currentFeeds[target]
+
+		var sources []string
+		for _, tag := range vanished {
+			if !consumed[tag] && subset(currentFeeds[tag], desiredFeeds[target]) {
+				sources = append(sources, tag)
+			}
+		}
+
+		switch {
+		case !exists && len(sources) == 1 && len(currentFeeds[sources[0]]) == len(desiredFeeds[target]):
+			plan.Changes = append(plan.Changes, TagChange{
+				Kind:    TagChangeRename,
+				From:    sources,
+				To:      target,
+				FeedIDs: sortedFeeds(currentFeeds[sources[0]]),
+			})
+		case len(sources) >= 2 || (exists && len(sources) == 1):
+			feeds := make(map[int]bool)
+			for _, source := range sources {
+				for feedID := range currentFeeds[source] {
+					if !currentFeeds[target][feedID] {
+						feeds[feedID] = true
+					}
+				}
+			}
+			plan.Changes = append(plan.Changes, TagChange{
+				Kind:    TagChangeMerge,
+				From:    sources,
+				To:      target,
+				FeedIDs: sortedFeeds(feeds),
+			})
+		default:
+			continue
+		}
+
+		for _, source := range sources {
+			consumed[source] = true
+			for feedID := range currentFeeds[source] {
+				covered[pairKey(feedID, source)] = true
+				covered[pairKey(feedID, target)] = true
+			}
+		}
+	}
+
+	var removes []TagChange
+	for _, feedID := range r.allFeeds {
+		for _, tag := range boolKeys(r.desired[feedID]) {
+			if r.current[feedID][tag] == nil && !covered[pairKey(feedID, tag)] {
+				plan.Changes = append(plan.Changes, TagChange{
+					Kind:      TagChangeAdd,
+					To:        tag,
+					FeedID:    feedID,
+					FeedTitle: r.titles[feedID],
+				})
+			}
+		}
+
+		for _, tag := range keys(r.current[feedID]) {
+			if !r.desired[feedID][tag] && !covered[pairKey(feedID, tag)] {
+				removes = append(removes, TagChange{
+					Kind:      TagChangeRemove,
+					To:        tag,
+					FeedID:    feedID,
+					FeedTitle: r.titles[feedID],
+					TaggingID: r.current[feedID][tag].ID,
+				})
+			}
+		}
+	}
+
+	plan.Changes = append(plan.Changes, removes...)
+	return plan
+}
+
+// Apply makes the changes of a plan in order, calling progress after each
+// one. It stops at the first failure with a *TagApplyError.
+func (r *TagReorg) Apply(plan *TagPlan, progress func(done, total int, change TagChange)) error {
+	for i, change := range plan.Changes {
+		if err := r.apply(change); err != nil {
+			return &TagApplyError{Applied: i, Change: change, Err: err}
+		}
+
+		if progress != nil {
+			progress(i+1, len(plan.Changes), change)
+		}
+	}
+
+	return nil
+}
+
+// apply makes a single change
+func (r *TagReorg) apply(change TagChange) error {
+	c := r.client
+
+	switch change.Kind {
+	case TagChangeRename:
+		_, _, err := c.Tags.Rename(change.From[0], change.To)
+		return err
+	case TagChangeMerge:
+		// Tag the feeds first so none of them is left untagged if a
+		// later step fails
+		for _, feedID := range change.FeedIDs {
+			if _, _, err := c.Taggings.Create(feedID, 0, change.To); err != nil {
+				return err
+			}
+		}
+		for _, source := range change.From {
+			if _, _, err := c.Tags.Delete(source); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TagChangeAdd:
+		_, _, err := c.Taggings.Create(change.FeedID, 0, change.To)
+		return err
+	case TagChangeRemove:
+		_, err := c.Taggings.Delete(change.TaggingID)
+		return err
+	default:
+		return fmt.Errorf("unknown tag change %q", change.Kind)
+	}
+}
+
+// tagFeeds inverts a feed to tags mapping
+func tagFeeds(feedIDs []int, tagsOf func(int) []string) map[string]map[int]bool {
+	feeds := make(map[string]map[int]bool)
+	for _, feedID := range feedIDs {
+		for _, tag := range tagsOf(feedID) {
+			if feeds[tag] == nil {
+				feeds[tag] = make(map[int]bool)
+			}
+			feeds[tag][feedID] = true
+		}
+	}
+	return feeds
+}
+
+// subset reports whether a is a non-empty subset of b
+func subset(a, b map[int]bool) bool {
+	if len(a) == 0 {
+		return false
+	}
+	for feedID := range a {
+		if !b[feedID] {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedFeeds returns the feed IDs of a set in order
+func sortedFeeds(set map[int]bool) []int {
+	feedIDs := make([]int, 0, len(set))
+	for feedID := range set {
+		feedIDs = append(feedIDs, feedID)
+	}
+	sort.Ints(feedIDs)
+	return feedIDs
+}
+
+// keys returns the sorted tag names of a feed's current taggings
+func keys(tags map[string]*Tagging) []string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// boolKeys returns the sorted tag names of a set
+func boolKeys(tags map[string]bool) []string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pairKey identifies a feed and tag pair
+func pairKey(feedID int, tag string) string {
+	return fmt.Sprintf("%d\x00%s", feedID, tag)
+}
+
+// quoteTags quotes and joins tag names
+func quoteTags(tags []string) string {
+	quoted := make([]string, len(tags))
+	for i, tag := range tags {
+		quoted[i] = fmt.Sprintf("%q", tag)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// feedLabel names a feed in a plan
+func feedLabel(feedID int, title string) string {
+	if title == "" {
+		return fmt.Sprintf("feed %d", feedID)
+	}
+	return fmt.Sprintf("%s (feed %d)", title, feedID)
+}
+
+// plural formats a count with a singular or plural noun
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
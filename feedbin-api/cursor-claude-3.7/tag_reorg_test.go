@@ -0,0 +1,165 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTagReorgTestServer serves three feeds and their taggings, records every
+// mutation and fails the tagging of failTag
+func newTagReorgTestServer(t *testing.T, failTag string) (*Client, *[]string) {
+	var mu sync.Mutex
+	var mutations []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if r.Method == http.MethodGet {
+			switch r.URL.Path {
+			case "/v2/subscriptions.json":
+				json.NewEncoder(w).Encode([]Subscription{
+					{ID: 1, FeedID: 1, Title: "Go Blog"},
+					{ID: 2, FeedID: 2, Title: "Rust Blog"},
+					{ID: 3, FeedID: 3, Title: "News"},
+				})
+			case "/v2/taggings.json":
+				json.NewEncoder(w).Encode([]Tagging{
+					{ID: 10, FeedID: 1, Name: "dev"},
+					{ID: 11, FeedID: 2, Name: "dev"},
+					{ID: 12, FeedID: 2, Name: "old"},
+					{ID: 13, FeedID: 3, Name: "news"},
+					{ID: 14, FeedID: 3, Name: "misc"},
+					{ID: 15, FeedID: 3, Name: "archive"},
+					{ID: 16, FeedID: 1, Name: "misc"},
+				})
+			}
+			return
+		}
+
+		mu.Lock()
+		mutations = append(mutations, strings.TrimSpace(fmt.Sprintf("%s %s %s", r.Method, strings.TrimPrefix(r.URL.Path, "/v2/"), body)))
+		mu.Unlock()
+
+		if failTag != "" && strings.Contains(string(body), `"name":"`+failTag+`"`) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Path == "/v2/tags.json" {
+			w.Write([]byte("[]"))
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient("user", "pass")
+	client.BaseURL, _ = url.Parse(server.URL + "/v2/")
+	return client, &mutations
+}
+
+// newTestReorg renames dev to code, merges old into archive, adds tech to
+// the News feed and removes misc from it
+func newTestReorg(t *testing.T, client *Client) *TagReorg {
+	reorg, err := client.NewTagReorg()
+	if err != nil {
+		t.Fatalf("NewTagReorg returned error: %v", err)
+	}
+
+	reorg.Rename("dev", "code")
+	if err := reorg.SetFeedTags(3, "archive", "news", "tech"); err != nil {
+		t.Fatalf("SetFeedTags returned error: %v", err)
+	}
+	reorg.Merge("archive", "old")
+
+	return reorg
+}
+
+func TestTagReorgPlanOrder(t *testing.T) {
+	client, _ := newTagReorgTestServer(t, "")
+	plan := newTestReorg(t, client).Plan()
+
+	var got []string
+	for _, change := range plan.Changes {
+		got = append(got, change.String())
+	}
+
+	want := []string{
+		`merge "old" -> "archive" (1 feed)`,
+		`rename "dev" -> "code" (2 feeds)`,
+		`add "tech" to News (feed 3)`,
+		`remove "misc" from News (feed 3)`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Plan = %q, want %q", got, want)
+	}
+
+	if !reflect.DeepEqual(plan.Changes[0].FeedIDs, []int{2}) || !reflect.DeepEqual(plan.Changes[1].FeedIDs, []int{1, 2}) {
+		t.Errorf("Plan feeds = %v and %v, want [2] and [1 2]", plan.Changes[0].FeedIDs, plan.Changes[1].FeedIDs)
+	}
+	if plan.Changes[3].TaggingID != 14 {
+		t.Errorf("Remove tagging ID = %d, want 14", plan.Changes[3].TaggingID)
+	}
+}
+
+func TestTagReorgApply(t *testing.T) {
+	client, mutations := newTagReorgTestServer(t, "")
+	reorg := newTestReorg(t, client)
+
+	var progress []int
+	err := reorg.Apply(reorg.Plan(), func(done, total int, change TagChange) {
+		progress = append(progress, done)
+	})
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+
+	want := []string{
+		`POST taggings.json {"feed_id":2,"name":"archive"}`,
+		`DELETE tags.json {"name":"old"}`,
+		`POST tags.json {"old_name":"dev","new_name":"code"}`,
+		`POST taggings.json {"feed_id":3,"name":"tech"}`,
+		`DELETE taggings/14.json`,
+	}
+	if !reflect.DeepEqual(*mutations, want) {
+		t.Errorf("Mutations = %q, want %q", *mutations, want)
+	}
+	if !reflect.DeepEqual(progress, []int{1, 2, 3, 4}) {
+		t.Errorf("Progress = %v, want [1 2 3 4]", progress)
+	}
+}
+
+func TestTagReorgApplyStopsAtFailure(t *testing.T) {
+	client, mutations := newTagReorgTestServer(t, "tech")
+	reorg := newTestReorg(t, client)
+
+	err := reorg.Apply(reorg.Plan(), nil)
+
+	var applyErr *TagApplyError
+	if !errors.As(err, &applyErr) {
+		t.Fatalf("Apply returned %v, want a *TagApplyError", err)
+	}
+	if applyErr.Applied != 2 || applyErr.Change.Kind != TagChangeAdd || applyErr.Change.To != "tech" {
+		t.Errorf("TagApplyError = %+v, want a failed add of tech after 2 changes", applyErr)
+	}
+
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Apply error does not wrap the API error: %v", err)
+	}
+
+	// The remove after the failed add is never sent
+	for _, mutation := range *mutations {
+		if strings.HasPrefix(mutation, "DELETE taggings/") {
+			t.Errorf("Unexpected mutation after the failure: %s", mutation)
+		}
+	}
+}
@@ -26,3 +26,57 @@ func (s *TagsService) List() ([]*Tag, *http.Response, error) {
 
 	return tags, resp, nil
 }
+
+// RenameTagRequest represents a request to rename a tag
+type RenameTagRequest struct {
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+}
+
+// DeleteTagRequest represents a request to delete a tag
+type DeleteTagRequest struct {
+	Name string `json:"name"`
+}
+
+// Rename renames a tag and returns the taggings after the rename
+// https://github.com/feedbin/feedbin-api/blob/master/content/tags.md#post-v2tagsjson
+func (s *TagsService) Rename(oldName, newName string) ([]*Tagging, *http.Response, error) {
+	request := &RenameTagRequest{
+		OldName: oldName,
+		NewName: newName,
+	}
+
+	req, err := s.client.NewRequest(http.MethodPost, "tags.json", request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var taggings []*Tagging
+	resp, err := s.client.Do(req, &taggings)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return taggings, resp, nil
+}
+
+// Delete removes a tag from every feed and returns the remaining taggings
+// https://github.com/feedbin/feedbin-api/blob/master/content/tags.md#delete-v2tagsjson
+func (s *TagsService) Delete(name string) ([]*Tagging, *http.Response, error) {
+	request := &DeleteTagRequest{
+		Name: name,
+	}
+
+	req, err := s.client.NewRequest(http.MethodDelete, "tags.json", request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var taggings []*Tagging
+	resp, err := s.client.Do(req, &taggings)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return taggings, resp, nil
+}
@@ -8,6 +8,18 @@ import (
 // endpoints of the Feedbin API
 type UnreadService struct {
 	client *Client
+
+	// label is recorded in the client's journal with each mutation
+	label string
+
+	// skipJournal is set for mutations the journal records itself
+	skipJournal bool
+}
+
+// WithLabel returns a copy of the service whose mutations are journaled
+// with label
+func (s *UnreadService) WithLabel(label string) *UnreadService {
+	return &UnreadService{client: s.client, label: label}
 }
 
 // UnreadEntriesRequest represents a request to mark entries as read or unread
@@ -54,6 +66,10 @@ func (s *UnreadService) MarkAsUnread(entryIDs []int) ([]int, *http.Response, err
 		return nil, resp, err
 	}
 
+	if err := s.journal(JournalMarkUnread, markedIDs); err != nil {
+		return markedIDs, resp, err
+	}
+
 	return markedIDs, resp, nil
 }
 
@@ -79,6 +95,10 @@ func (s *UnreadService) MarkAsRead(entryIDs []int) ([]int, *http.Response, error
 		return nil, resp, err
 	}
 
+	if err := s.journal(JournalMarkRead, markedIDs); err != nil {
+		return markedIDs, resp, err
+	}
+
 	return markedIDs, resp, nil
 }
 
@@ -105,5 +125,18 @@ func (s *UnreadService) MarkAsReadAlternative(entryIDs []int) ([]int, *http.Resp
 		return nil, resp, err
 	}
 
+	if err := s.journal(JournalMarkRead, markedIDs); err != nil {
+		return markedIDs, resp, err
+	}
+
 	return markedIDs, resp, nil
 }
+
+// journal records a confirmed mutation in the client's journal
+func (s *UnreadService) journal(action string, markedIDs []int) error {
+	if s.skipJournal {
+		return nil
+	}
+
+	return s.client.journal(action, s.label, markedIDs)
+}
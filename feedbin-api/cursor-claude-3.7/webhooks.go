@@ -0,0 +1,730 @@
+package feedbin
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook event types
+const (
+	EventEntryCreated   = "entry.created"
+	EventEntryStarred   = "entry.starred"
+	EventEntryUnstarred = "entry.unstarred"
+	EventEntryRead      = "entry.read"
+	EventEntryUnread    = "entry.unread"
+)
+
+// WebhookFormat is the payload format sent to a webhook target
+type WebhookFormat string
+
+// Webhook payload formats
+const (
+	// WebhookFormatJSON sends the events as a generic JSON document
+	WebhookFormatJSON WebhookFormat = "json"
+	// WebhookFormatSlack sends a formatted message accepted by Slack and
+	// Mattermost incoming webhooks
+	WebhookFormatSlack WebhookFormat = "slack"
+)
+
+const (
+	// DefaultWebhookInterval is how often the dispatcher polls Feedbin
+	DefaultWebhookInterval = 5 * time.Minute
+	// DefaultWebhookMaxAttempts is how many times a delivery is tried
+	DefaultWebhookMaxAttempts = 5
+	// DefaultWebhookBackoff is the delay before the first retry; it doubles
+	// after every failed attempt
+	DefaultWebhookBackoff = time.Second
+	// MaxWebhookPending is how many failed events are kept per target
+	// without a dead-letter file; older events are dropped beyond it
+	MaxWebhookPending = 1000
+)
+
+// Webhook request headers
+const (
+	WebhookSignatureHeader = "X-Feedbin-Signature"
+	WebhookTimestampHeader = "X-Feedbin-Timestamp"
+)
+
+// WebhookTarget is a URL that receives webhook events
+type WebhookTarget struct {
+	// Name identifies the target in the dead-letter file
+	Name string `json:"name"`
+
+	URL    string        `json:"url"`
+	Format WebhookFormat `json:"format,omitempty"`
+
+	// Secret signs payloads with HMAC-SHA256 when set
+	Secret string `json:"secret,omitempty"`
+
+	// Events limits the event types sent; empty sends every type
+	Events []string `json:"events,omitempty"`
+
+	// FeedIDs and Tags limit events to entries from these feeds or from
+	// feeds with these tags; both empty sends entries from every feed
+	FeedIDs []int    `json:"feed_ids,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+
+	// MaxAttempts and Backoff control retries of failed deliveries
+	MaxAttempts int           `json:"max_attempts,omitempty"`
+	Backoff     time.Duration `json:"backoff,omitempty"`
+}
+
+// WebhookEvent is a change observed between two polls
+type WebhookEvent struct {
+	Type       string    `json:"type"`
+	EntryID    int       `json:"entry_id"`
+	Entry      *Entry    `json:"entry,omitempty"`
+	FeedTitle  string    `json:"feed_title,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// WebhookPayload is the body sent to targets using WebhookFormatJSON
+type WebhookPayload struct {
+	Events []*WebhookEvent `json:"events"`
+}
+
+// DeadLetter is a delivery that failed every attempt. Dead letters are
+// appended to the dead-letter file as one JSON document per line.
+type DeadLetter struct {
+	Target   string          `json:"target"`
+	URL      string          `json:"url"`
+	Payload  json.RawMessage `json:"payload"`
+	Error    string          `json:"error"`
+	Attempts int             `json:"attempts"`
+	FailedAt time.Time       `json:"failed_at"`
+}
+
+// webhookState is what the dispatcher remembers between polls
+type webhookState struct {
+	Since   string `json:"since"`
+	Starred []int  `json:"starred"`
+	Unread  []int  `json:"unread"`
+
+	// Pending holds, per target, events whose delivery failed without a
+	// dead-letter file; they are sent again with the next poll's events.
+	// Only the newest MaxWebhookPending events are kept per target.
+	Pending map[string][]*WebhookEvent `json:"pending,omitempty"`
+}
+
+// WebhookDispatcher polls Feedbin for new entries and starred and unread
+// changes, and posts them to webhook targets
+type WebhookDispatcher struct {
+	client *Client
+
+	// Targets receive the events
+	Targets []*WebhookTarget
+
+	// StatePath is where the since bound and the last starred and unread
+	// IDs are kept between runs; when empty they only live in memory
+	StatePath string
+
+	// DeadLetterPath receives deliveries that failed every attempt
+	DeadLetterPath string
+
+	// Interval between polls in Run
+	Interval time.Duration
+
+	// HTTPClient posts to the targets, defaults to a client with a 30 second timeout
+	HTTPClient *http.Client
+
+	// OnError is called with errors that Run recovers from
+	OnError func(error)
+
+	state *webhookState
+}
+
+// NewWebhookDispatcher returns a dispatcher that posts to targets
+func NewWebhookDispatcher(client *Client, statePath string, targets ...*WebhookTarget) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		client:     client,
+		Targets:    targets,
+		StatePath:  statePath,
+		Interval:   DefaultWebhookInterval,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ErrNoWebhookTargets is returned by Run when the dispatcher has no targets
+var ErrNoWebhookTargets = errors.New("webhook dispatcher has no targets")
+
+// Run polls until ctx is cancelled. Poll errors are passed to OnError and
+// retried on the next interval.
+func (d *WebhookDispatcher) Run(ctx context.Context) error {
+	if len(d.Targets) == 0 {
+		return ErrNoWebhookTargets
+	}
+
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultWebhookInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.OnError != nil {
+				d.OnError(err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches the changes since the previous poll, delivers them and saves
+// the new state. The first poll only records a baseline, taken from the
+// server's Date header, and sends nothing.
+// Deliveries that fail every attempt go to the dead-letter file and do not
+// fail the poll. Without a dead-letter file they fail it, but the other
+// targets are still delivered to and the state is saved; the failed events
+// are kept for that target only, up to MaxWebhookPending, and sent again by
+// the next poll.
+func (d *WebhookDispatcher) Poll(ctx context.Context) ([]*WebhookEvent, error) {
+	state, err := d.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	starred, starredResp, err := d.client.Starred.List()
+	if err != nil {
+		return nil, err
+	}
+
+	unread, unreadResp, err := d.client.Unread.List()
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Since == "" {
+		d.state = &webhookState{
+			Since:   serverDate(starredResp, unreadResp).Format("2006-01-02T15:04:05.000000Z"),
+			Starred: starred,
+			Unread:  unread,
+		}
+		return nil, d.saveState()
+	}
+
+	created, since, err := d.newEntries(state.Since)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	isNew := make(map[int]bool)
+	var events []*WebhookEvent
+	for _, entry := range created {
+		isNew[entry.ID] = true
+		events = append(events, &WebhookEvent{Type: EventEntryCreated, EntryID: entry.ID, Entry: entry, OccurredAt: now})
+	}
+
+	added, removed := diffIDs(state.Starred, starred)
+	events = append(events, idEvents(EventEntryStarred, added, now)...)
+	events = append(events, idEvents(EventEntryUnstarred, removed, now)...)
+
+	// New entries arrive unread, so only entries marked unread again count
+	added, removed = diffIDs(state.Unread, unread)
+	var markedUnread []int
+	for _, id := range added {
+		if !isNew[id] {
+			markedUnread = append(markedUnread, id)
+		}
+	}
+	events = append(events, idEvents(EventEntryUnread, markedUnread, now)...)
+	events = append(events, idEvents(EventEntryRead, removed, now)...)
+
+	if len(events) > 0 {
+		if err := d.describe(events); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	pending := make(map[string][]*WebhookEvent)
+	for _, target := range d.Targets {
+		key := target.key()
+		matched := append(state.Pending[key], target.filter(events)...)
+		if len(matched) == 0 {
+			continue
+		}
+		if err := d.deliver(ctx, target, matched); err != nil {
+			if len(matched) > MaxWebhookPending {
+				matched = matched[len(matched)-MaxWebhookPending:]
+			}
+			pending[key] = matched
+			errs = append(errs, err)
+		}
+	}
+
+	d.state = &webhookState{Since: since, Starred: starred, Unread: unread, Pending: pending}
+	if err := d.saveState(); err != nil {
+		return nil, err
+	}
+
+	return events, errors.Join(errs...)
+}
+
+// key identifies the target in the saved state
+func (t *WebhookTarget) key() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.URL
+}
+
+// serverDate returns the time in the Date header of the first response that
+// has one, falling back to the local clock
+func serverDate(responses ...*http.Response) time.Time {
+	for _, resp := range responses {
+		if resp == nil {
+			continue
+		}
+		if t, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+			return t.UTC()
+		}
+	}
+
+	return time.Now().UTC()
+}
+
+// newEntries returns every entry created after since, following the Link
+// header, along with the since bound for the next poll
+func (d *WebhookDispatcher) newEntries(since string) ([]*Entry, string, error) {
+	opts := &EntryOptions{PageOptions: PageOptions{Since: since}}
+	path, err := AddQueryParams("entries.json", opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := since
+	newest, _ := time.Parse(time.RFC3339Nano, since)
+
+	var entries []*Entry
+	for path != "" {
+		req, err := d.client.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var page []*Entry
+		resp, err := d.client.Do(req, &page)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, entry := range page {
+			entries = append(entries, entry)
+			if entry.CreatedAt.After(newest) {
+				newest = entry.CreatedAt
+				next = entry.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000Z")
+			}
+		}
+
+		path = ""
+		links, err := ParseLinkHeader(resp)
+		if err != nil {
+			return nil, "", err
+		}
+		if links != nil && links.NextURL != nil && len(page) > 0 {
+			path = links.NextURL.String()
+		}
+	}
+
+	return entries, next, nil
+}
+
+// describe fills in the entry, feed title and tags of every event
+func (d *WebhookDispatcher) describe(events []*WebhookEvent) error {
+	var missing []int
+	for _, event := range events {
+		if event.Entry == nil {
+			missing = append(missing, event.EntryID)
+		}
+	}
+
+	entries := make(map[int]*Entry)
+	for start := 0; start < len(missing); start += 100 {
+		end := start + 100
+		if end > len(missing) {
+			end = len(missing)
+		}
+
+		batch, _, err := d.client.Entries.GetByIDs(missing[start:end])
+		if err != nil {
+			return err
+		}
+		for _, entry := range batch {
+			entries[entry.ID] = entry
+		}
+	}
+
+	subscriptions, _, err := d.client.Subscriptions.List(nil)
+	if err != nil {
+		return err
+	}
+
+	titles := make(map[int]string)
+	for _, subscription := range subscriptions {
+		titles[subscription.FeedID] = subscription.Title
+	}
+
+	taggings, _, err := d.client.Taggings.List()
+	if err != nil {
+		return err
+	}
+
+	tags := make(map[int][]string)
+	for _, tagging := range taggings {
+		tags[tagging.FeedID] = append(tags[tagging.FeedID], tagging.Name)
+	}
+
+	for _, event := range events {
+		if event.Entry == nil {
+			event.Entry = entries[event.EntryID]
+		}
+		if event.Entry != nil {
+			event.FeedTitle = titles[event.Entry.FeedID]
+			event.Tags = tags[event.Entry.FeedID]
+		}
+	}
+
+	return nil
+}
+
+// filter returns the events the target is interested in
+func (t *WebhookTarget) filter(events []*WebhookEvent) []*WebhookEvent {
+	var matched []*WebhookEvent
+	for _, event := range events {
+		if len(t.Events) > 0 && !containsString(t.Events, event.Type) {
+			continue
+		}
+
+		if len(t.FeedIDs) > 0 || len(t.Tags) > 0 {
+			if event.Entry == nil {
+				continue
+			}
+
+			feedMatch := containsInt(t.FeedIDs, event.Entry.FeedID)
+			tagMatch := false
+			for _, tag := range event.Tags {
+				if containsString(t.Tags, tag) {
+					tagMatch = true
+					break
+				}
+			}
+
+			if !feedMatch && !tagMatch {
+				continue
+			}
+		}
+
+		matched = append(matched, event)
+	}
+
+	return matched
+}
+
+// deliver posts events to a target, retrying with backoff, and records a
+// dead letter when every attempt fails
+func (d *WebhookDispatcher) deliver(ctx context.Context, target *WebhookTarget, events []*WebhookEvent) error {
+	payload, err := target.payload(events)
+	if err != nil {
+		return err
+	}
+
+	maxAttempts := target.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultWebhookMaxAttempts
+	}
+
+	backoff := target.Backoff
+	if backoff <= 0 {
+		backoff = DefaultWebhookBackoff
+	}
+
+	var lastErr error
+	attempts := 0
+	for attempts < maxAttempts {
+		if attempts > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		attempts++
+
+		retry, err := d.post(ctx, target, payload)
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+
+	return d.deadLetter(&DeadLetter{
+		Target:   target.Name,
+		URL:      target.URL,
+		Payload:  payload,
+		Error:    lastErr.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now().UTC(),
+	})
+}
+
+// post sends one delivery attempt and reports whether a failure is worth
+// retrying. Network errors, 429 and 5xx responses are retried.
+func (d *WebhookDispatcher) post(ctx context.Context, target *WebhookTarget, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", d.client.UserAgent)
+
+	if target.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(target.Secret, timestamp, payload))
+	}
+
+	httpClient := d.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("webhook %s returned %d", target.URL, resp.StatusCode)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// payload renders events in the target's format
+func (t *WebhookTarget) payload(events []*WebhookEvent) ([]byte, error) {
+	switch t.Format {
+	case "", WebhookFormatJSON:
+		return json.Marshal(&WebhookPayload{Events: events})
+	case WebhookFormatSlack:
+		return json.Marshal(map[string]string{"text": slackText(events)})
+	default:
+		return nil, fmt.Errorf("unknown webhook format %q for %s", t.Format, t.URL)
+	}
+}
+
+// slackText formats events as Slack and Mattermost markdown
+func slackText(events []*WebhookEvent) string {
+	labels := map[string]string{
+		EventEntryCreated:   "New",
+		EventEntryStarred:   "Starred",
+		EventEntryUnstarred: "Unstarred",
+		EventEntryRead:      "Read",
+		EventEntryUnread:    "Unread",
+	}
+
+	lines := make([]string, 0, len(events))
+	for _, event := range events {
+		label := labels[event.Type]
+		if event.Entry == nil {
+			lines = append(lines, fmt.Sprintf("*%s:* entry %d", label, event.EntryID))
+			continue
+		}
+
+		title := event.Entry.URL
+		if event.Entry.Title != nil && *event.Entry.Title != "" {
+			title = *event.Entry.Title
+		}
+
+		line := fmt.Sprintf("*%s:* <%s|%s>", label, event.Entry.URL, slackEscape(title))
+		if event.FeedTitle != "" {
+			line += " — " + slackEscape(event.FeedTitle)
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// slackEscape escapes the characters Slack treats as markup
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// SignWebhookPayload returns the signature header value for a payload: the
+// hex HMAC-SHA256 of the timestamp, a dot and the body
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature was produced for the
+// payload and timestamp with secret. Receivers should also reject old
+// timestamps to prevent replays.
+func VerifyWebhookSignature(secret, timestamp string, payload []byte, signature string) bool {
+	expected := SignWebhookPayload(secret, timestamp, payload)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// deadLetter appends a failed delivery to the dead-letter file
+func (d *WebhookDispatcher) deadLetter(letter *DeadLetter) error {
+	if d.DeadLetterPath == "" {
+		return fmt.Errorf("delivery to %s failed after %d attempts: %s", letter.URL, letter.Attempts, letter.Error)
+	}
+
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(d.DeadLetterPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// loadState returns the in-memory state, reading StatePath the first time
+func (d *WebhookDispatcher) loadState() (*webhookState, error) {
+	if d.state != nil {
+		return d.state, nil
+	}
+
+	d.state = &webhookState{}
+	if d.StatePath == "" {
+		return d.state, nil
+	}
+
+	data, err := os.ReadFile(d.StatePath)
+	if os.IsNotExist(err) {
+		return d.state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, d.state); err != nil {
+		return nil, fmt.Errorf("error reading webhook state %s: %w", d.StatePath, err)
+	}
+
+	return d.state, nil
+}
+
+// saveState writes the state to StatePath
+func (d *WebhookDispatcher) saveState() error {
+	if d.StatePath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(d.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := d.StatePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, d.StatePath)
+}
+
+// idEvents returns one event of type for every ID
+func idEvents(eventType string, ids []int, at time.Time) []*WebhookEvent {
+	events := make([]*WebhookEvent, len(ids))
+	for i, id := range ids {
+		events[i] = &WebhookEvent{Type: eventType, EntryID: id, OccurredAt: at}
+	}
+	return events
+}
+
+// diffIDs returns the IDs added to and removed from before, in ascending order
+func diffIDs(before, after []int) ([]int, []int) {
+	previous := make(map[int]bool, len(before))
+	for _, id := range before {
+		previous[id] = true
+	}
+
+	current := make(map[int]bool, len(after))
+	var added []int
+	for _, id := range after {
+		current[id] = true
+		if !previous[id] {
+			added = append(added, id)
+		}
+	}
+
+	var removed []int
+	for _, id := range before {
+		if !current[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	sort.Ints(added)
+	sort.Ints(removed)
+
+	return added, removed
+}
+
+// containsInt reports whether ids contains id
+func containsInt(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,319 @@
+package feedbin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFeedbin serves the endpoints the webhook dispatcher polls
+type fakeFeedbin struct {
+	mu      sync.Mutex
+	starred []int
+	unread  []int
+	entries map[int]*Entry
+	date    time.Time
+}
+
+func newFakeFeedbin(t *testing.T) (*fakeFeedbin, *Client) {
+	title := "Go 1.22 <released>"
+	f := &fakeFeedbin{
+		entries: map[int]*Entry{
+			5: {ID: 5, FeedID: 1, Title: &title, URL: "https://go.dev/blog/go1.22"},
+		},
+	}
+
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	client := NewClient("user", "pass")
+	client.BaseURL, _ = url.Parse(server.URL + "/v2/")
+	return f, client
+}
+
+func (f *fakeFeedbin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.date.IsZero() {
+		w.Header().Set("Date", f.date.Format(http.TimeFormat))
+	}
+
+	switch r.URL.Path {
+	case "/v2/starred_entries.json":
+		json.NewEncoder(w).Encode(f.starred)
+	case "/v2/unread_entries.json":
+		json.NewEncoder(w).Encode(f.unread)
+	case "/v2/entries.json":
+		var entries []*Entry
+		for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			for _, entry := range f.entries {
+				if id != "" && strings.TrimSpace(id) == itoa(entry.ID) {
+					entries = append(entries, entry)
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(entries)
+	case "/v2/subscriptions.json":
+		json.NewEncoder(w).Encode([]Subscription{{ID: 1, FeedID: 1, Title: "The Go Blog"}})
+	case "/v2/taggings.json":
+		json.NewEncoder(w).Encode([]Tagging{{ID: 1, FeedID: 1, Name: "Tech"}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeFeedbin) star(ids ...int) {
+	f.mu.Lock()
+	f.starred = append(f.starred, ids...)
+	f.mu.Unlock()
+}
+
+func itoa(n int) string {
+	data, _ := json.Marshal(n)
+	return string(data)
+}
+
+// webhookReceiver records deliveries and answers with the next status
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+
+	status := http.StatusOK
+	if len(rcv.statuses) > 0 {
+		status = rcv.statuses[0]
+		if len(rcv.statuses) > 1 {
+			rcv.statuses = rcv.statuses[1:]
+		}
+	}
+	w.WriteHeader(status)
+}
+
+func (rcv *webhookReceiver) count() int {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return len(rcv.bodies)
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) (*webhookReceiver, string) {
+	rcv := &webhookReceiver{statuses: statuses}
+	server := httptest.NewServer(rcv)
+	t.Cleanup(server.Close)
+	return rcv, server.URL
+}
+
+func TestWebhookDispatcherSignsAndRetries(t *testing.T) {
+	feedbin, client := newFakeFeedbin(t)
+	rcv, receiverURL := newWebhookReceiver(t, http.StatusServiceUnavailable, http.StatusOK)
+
+	target := &WebhookTarget{Name: "signed", URL: receiverURL, Secret: "s3cret", Backoff: time.Millisecond}
+	dispatcher := NewWebhookDispatcher(client, filepath.Join(t.TempDir(), "state.json"), target)
+
+	// The first poll records a baseline
+	if _, err := dispatcher.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+
+	feedbin.star(5)
+	events, err := dispatcher.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+
+	if len(events) != 1 || events[0].Type != EventEntryStarred || events[0].FeedTitle != "The Go Blog" {
+		t.Fatalf("Poll events = %+v, want one starred event", events)
+	}
+
+	if rcv.count() != 2 {
+		t.Fatalf("Receiver got %d attempts, want 2", rcv.count())
+	}
+
+	req, body := rcv.requests[1], rcv.bodies[1]
+	timestamp := req.Header.Get(WebhookTimestampHeader)
+	if !VerifyWebhookSignature("s3cret", timestamp, body, req.Header.Get(WebhookSignatureHeader)) {
+		t.Error("Expected a valid payload signature")
+	}
+	if VerifyWebhookSignature("other", timestamp, body, req.Header.Get(WebhookSignatureHeader)) {
+		t.Error("Expected the signature to fail with another secret")
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Events) != 1 || payload.Events[0].EntryID != 5 {
+		t.Errorf("Payload = %s, want the starred event", body)
+	}
+}
+
+func TestWebhookDispatcherDeadLetters(t *testing.T) {
+	feedbin, client := newFakeFeedbin(t)
+	rcv, receiverURL := newWebhookReceiver(t, http.StatusInternalServerError)
+
+	dir := t.TempDir()
+	target := &WebhookTarget{Name: "broken", URL: receiverURL, MaxAttempts: 2, Backoff: time.Millisecond}
+	dispatcher := NewWebhookDispatcher(client, filepath.Join(dir, "state.json"), target)
+	dispatcher.DeadLetterPath = filepath.Join(dir, "dead.jsonl")
+
+	dispatcher.Poll(context.Background())
+	feedbin.star(5)
+
+	if _, err := dispatcher.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+	if rcv.count() != 2 {
+		t.Errorf("Receiver got %d attempts, want 2", rcv.count())
+	}
+
+	f, err := os.Open(dispatcher.DeadLetterPath)
+	if err != nil {
+		t.Fatalf("Failed to open dead-letter file: %v", err)
+	}
+	defer f.Close()
+
+	var letters []DeadLetter
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var letter DeadLetter
+		json.Unmarshal(scanner.Bytes(), &letter)
+		letters = append(letters, letter)
+	}
+
+	if len(letters) != 1 || letters[0].Target != "broken" || letters[0].Attempts != 2 || !strings.Contains(letters[0].Error, "500") {
+		t.Errorf("Dead letters = %+v, want one letter after 2 attempts", letters)
+	}
+}
+
+func TestWebhookDispatcherPartialFailure(t *testing.T) {
+	feedbin, client := newFakeFeedbin(t)
+	ok, okURL := newWebhookReceiver(t)
+	failing, failingURL := newWebhookReceiver(t, http.StatusBadGateway, http.StatusOK)
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	targets := []*WebhookTarget{
+		{Name: "ok", URL: okURL},
+		{Name: "failing", URL: failingURL, MaxAttempts: 1},
+	}
+	dispatcher := NewWebhookDispatcher(client, statePath, targets...)
+
+	dispatcher.Poll(context.Background())
+	feedbin.star(5)
+
+	if _, err := dispatcher.Poll(context.Background()); err == nil {
+		t.Fatal("Expected the failed delivery without a dead-letter file to fail the poll")
+	}
+	if ok.count() != 1 || failing.count() != 1 {
+		t.Fatalf("Receivers got %d and %d deliveries, want 1 each", ok.count(), failing.count())
+	}
+
+	// A restarted dispatcher only sends the failed events to the failed target
+	restarted := NewWebhookDispatcher(client, statePath, targets...)
+	events, err := restarted.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+
+	if len(events) != 0 {
+		t.Errorf("Poll events = %+v, want no new events", events)
+	}
+	if ok.count() != 1 {
+		t.Errorf("Successful target got %d deliveries, want no duplicate", ok.count())
+	}
+	if failing.count() != 2 || !strings.Contains(string(failing.bodies[1]), `"entry.starred"`) {
+		t.Errorf("Failed target got %d deliveries, want the starred event again", failing.count())
+	}
+
+	// Once delivered, nothing is pending
+	restarted.Poll(context.Background())
+	if failing.count() != 2 {
+		t.Errorf("Failed target got %d deliveries, want the pending events to be cleared", failing.count())
+	}
+}
+
+func TestWebhookDispatcherSlackFormat(t *testing.T) {
+	feedbin, client := newFakeFeedbin(t)
+	rcv, receiverURL := newWebhookReceiver(t)
+
+	target := &WebhookTarget{URL: receiverURL, Format: WebhookFormatSlack, Tags: []string{"Tech"}}
+	dispatcher := NewWebhookDispatcher(client, "", target)
+
+	dispatcher.Poll(context.Background())
+	feedbin.star(5)
+
+	if _, err := dispatcher.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+
+	var message map[string]string
+	if rcv.count() != 1 || json.Unmarshal(rcv.bodies[0], &message) != nil {
+		t.Fatalf("Expected one Slack message, got %d deliveries", rcv.count())
+	}
+
+	want := "*Starred:* <https://go.dev/blog/go1.22|Go 1.22 &lt;released&gt;> — The Go Blog"
+	if message["text"] != want {
+		t.Errorf("Slack text = %q, want %q", message["text"], want)
+	}
+}
+
+func TestWebhookDispatcherBaselineUsesServerDate(t *testing.T) {
+	feedbin, client := newFakeFeedbin(t)
+	feedbin.date = time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC)
+	_, targetURL := newWebhookReceiver(t)
+
+	dispatcher := NewWebhookDispatcher(client, "", &WebhookTarget{URL: targetURL})
+	if _, err := dispatcher.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll returned error: %v", err)
+	}
+
+	if want := "2024-06-02T10:00:00.000000Z"; dispatcher.state.Since != want {
+		t.Errorf("Baseline since = %s, want the server date %s", dispatcher.state.Since, want)
+	}
+}
+
+func TestWebhookDispatcherCapsPendingEvents(t *testing.T) {
+	feedbin, client := newFakeFeedbin(t)
+	failing, targetURL := newWebhookReceiver(t, http.StatusBadGateway)
+
+	dispatcher := NewWebhookDispatcher(client, "", &WebhookTarget{Name: "failing", URL: targetURL, MaxAttempts: 1})
+	dispatcher.Poll(context.Background())
+
+	ids := make([]int, MaxWebhookPending+10)
+	for i := range ids {
+		ids[i] = 100 + i
+	}
+	feedbin.star(ids...)
+
+	if _, err := dispatcher.Poll(context.Background()); err == nil {
+		t.Fatal("Expected the failed delivery to fail the poll")
+	}
+	if failing.count() != 1 {
+		t.Fatalf("Receiver got %d deliveries, want 1", failing.count())
+	}
+
+	pending := dispatcher.state.Pending["failing"]
+	if len(pending) != MaxWebhookPending {
+		t.Fatalf("Pending events = %d, want %d", len(pending), MaxWebhookPending)
+	}
+	if pending[0].EntryID != 110 {
+		t.Errorf("Oldest pending event is for entry %d, want the oldest events to be dropped", pending[0].EntryID)
+	}
+}
@@ -3,6 +3,7 @@ package feedbin
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -38,6 +39,20 @@ type Client struct {
 	// User agent used when communicating with the API.
 	userAgent string
 
+	// Collector that receives request metrics, if any.
+	metrics MetricsCollector
+
+	// Plan that collects mutating requests in dry-run mode, if any.
+	dryRun *DryRunPlan
+
+	// Rate limiter for API requests, if any, and the priority of this
+	// client's requests.
+	limiter  *RateLimiter
+	priority Priority
+
+	// Context of this client's requests, if any.
+	ctx context.Context
+
 	// API endpoints
 	Authentication *AuthenticationService
 	Subscriptions  *SubscriptionsService
@@ -66,7 +81,14 @@ func NewClient(username, password string) *Client {
 		userAgent: UserAgent,
 	}
 
-	// Initialize services
+	c.initServices()
+	c.Extract.baseURL, _ = url.Parse(DefaultExtractURL)
+
+	return c
+}
+
+// initServices creates the API services of the client.
+func (c *Client) initServices() {
 	c.Authentication = &AuthenticationService{client: c}
 	c.Subscriptions = &SubscriptionsService{client: c}
 	c.Entries = &EntriesService{client: c}
@@ -79,9 +101,31 @@ func NewClient(username, password string) *Client {
 	c.Icons = &IconsService{client: c}
 	c.Imports = &ImportsService{client: c}
 	c.Pages = &PagesService{client: c}
-	c.Extract = &ExtractService{client: c, username: username}
+	c.Extract = &ExtractService{client: c, username: c.username}
+}
 
-	return c
+// clone returns a copy of the client with its own services, sharing the
+// HTTP client and settings of c.
+func (c *Client) clone() *Client {
+	cc := &Client{
+		client:    c.client,
+		baseURL:   c.baseURL,
+		username:  c.username,
+		password:  c.password,
+		userAgent: c.userAgent,
+		metrics:   c.metrics,
+		dryRun:    c.dryRun,
+		limiter:   c.limiter,
+		priority:  c.priority,
+		ctx:       c.ctx,
+	}
+	cc.initServices()
+
+	extract := *c.Extract
+	extract.client = cc
+	cc.Extract = &extract
+
+	return cc
 }
 
 // SetBaseURL sets the base URL for API requests to a custom endpoint.
@@ -125,7 +169,12 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 		}
 	}
 
-	req, err := http.NewRequest(method, u.String(), buf)
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), buf)
 	if err != nil {
 		return nil, err
 	}
@@ -145,19 +194,65 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 // Do sends an API request and returns the API response. The API response is
 // JSON decoded and stored in the value pointed to by v, or returned as an
 // error if an API error has occurred.
+//
+// In dry-run mode, requests other than GET are not sent; see DryRunPlan.
+// With a rate limiter, Do waits for it before sending and retries requests
+// answered with 429 Too Many Requests; see RateLimiter.
 func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
+	if c.dryRun != nil && req.Method != http.MethodGet {
+		return c.simulate(req, v)
 	}
-	defer resp.Body.Close()
 
-	err = CheckResponse(resp)
-	if err != nil {
-		return resp, err
+	var resp *http.Response
+	var start time.Time
+	attempt := 1
+	for {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(req.Context(), req.Method != http.MethodGet, c.priority); err != nil {
+				return nil, err
+			}
+		}
+
+		start = time.Now()
+		var err error
+		resp, err = c.client.Do(req)
+		if err != nil {
+			c.observe(req, nil, start, 0, attempt, err)
+			return nil, err
+		}
+
+		if c.limiter == nil {
+			break
+		}
+		c.limiter.Observe(resp)
+
+		// Retry requests the server throttled if the body can be sent again
+		if resp.StatusCode != http.StatusTooManyRequests || attempt > c.limiter.MaxRetries ||
+			(req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+			break
+		}
+
+		n, _ := io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		c.observe(req, resp, start, n, attempt, nil)
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		attempt++
 	}
 
-	if v != nil {
+	// Count the response bytes for metrics
+	body := &countingReader{ReadCloser: resp.Body}
+	resp.Body = body
+	defer resp.Body.Close()
+
+	err := CheckResponse(resp)
+	if err == nil && v != nil {
 		if w, ok := v.(io.Writer); ok {
 			io.Copy(w, resp.Body)
 		} else {
@@ -168,6 +263,7 @@ func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
 		}
 	}
 
+	c.observe(req, resp, start, body.n, attempt, err)
 	return resp, err
 }
 
@@ -0,0 +1,495 @@
+package feedbin
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// DiffOp is the kind of a diff segment.
+type DiffOp string
+
+const (
+	// DiffEqual is text present in both versions.
+	DiffEqual DiffOp = "equal"
+
+	// DiffInsert is text only present in the current version.
+	DiffInsert DiffOp = "insert"
+
+	// DiffDelete is text only present in the original version.
+	DiffDelete DiffOp = "delete"
+)
+
+// maxDiffCells bounds the size of the table used by WordDiff. Larger changes
+// are reported as a deletion of the original followed by an insertion.
+const maxDiffCells = 4 << 20
+
+// DiffSegment is a run of text with the same diff operation. Text has its
+// whitespace collapsed to single spaces.
+type DiffSegment struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// EntryUpdate is an updated entry prepared for review.
+type EntryUpdate struct {
+	Entry *Entry `json:"-"`
+
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+
+	// TitleDiff is the word diff of the original and current titles, if the
+	// title changed.
+	TitleDiff []DiffSegment `json:"title_diff,omitempty"`
+
+	// ContentDiff is the diff rendered by the server, parsed into segments.
+	ContentDiff []DiffSegment `json:"content_diff,omitempty"`
+
+	// WordDiff is the word diff of the original and current content,
+	// computed locally. It is empty if the entry has no original.
+	WordDiff []DiffSegment `json:"word_diff,omitempty"`
+}
+
+// NewEntryUpdate prepares an entry fetched with IncludeOriginal and
+// IncludeContentDiff for review.
+func NewEntryUpdate(entry *Entry) *EntryUpdate {
+	u := &EntryUpdate{
+		Entry:       entry,
+		ID:          entry.ID,
+		Title:       entry.Title,
+		URL:         entry.URL,
+		ContentDiff: ParseContentDiff(entry.ContentDiff),
+	}
+
+	if entry.Original != nil {
+		if entry.Original.Title != entry.Title {
+			u.TitleDiff = WordDiff(entry.Original.Title, entry.Title)
+		}
+		u.WordDiff = WordDiff(entry.Original.Content, entry.Content)
+	}
+
+	return u
+}
+
+// Segments returns the diff to show for the entry: the local word diff if
+// there is one, or the server's diff otherwise.
+func (u *EntryUpdate) Segments() []DiffSegment {
+	if len(u.WordDiff) > 0 {
+		return u.WordDiff
+	}
+	return u.ContentDiff
+}
+
+// ANSI renders the update for a terminal, with insertions in green and
+// deletions in red and struck through.
+func (u *EntryUpdate) ANSI() string {
+	var b strings.Builder
+
+	b.WriteString("\x1b[1m")
+	if len(u.TitleDiff) > 0 {
+		b.WriteString(RenderDiffANSI(u.TitleDiff))
+		b.WriteString("\x1b[1m")
+	} else {
+		b.WriteString(u.Title)
+	}
+	fmt.Fprintf(&b, "\x1b[0m (%d)\n", u.ID)
+
+	if u.URL != "" {
+		fmt.Fprintf(&b, "\x1b[2m%s\x1b[0m\n", u.URL)
+	}
+
+	b.WriteString(RenderDiffANSI(u.Segments()))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// Markdown renders the update as Markdown, with insertions in bold and
+// deletions struck through.
+func (u *EntryUpdate) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString("### ")
+	if len(u.TitleDiff) > 0 {
+		b.WriteString(RenderDiffMarkdown(u.TitleDiff))
+	} else {
+		b.WriteString(escapeMarkdown(u.Title))
+	}
+	b.WriteString("\n\n")
+
+	if u.URL != "" {
+		fmt.Fprintf(&b, "<%s>\n\n", u.URL)
+	}
+
+	b.WriteString(RenderDiffMarkdown(u.Segments()))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// RenderDiffANSI renders segments with ANSI escape codes.
+func RenderDiffANSI(segments []DiffSegment) string {
+	parts := make([]string, len(segments))
+	for i, segment := range segments {
+		switch segment.Op {
+		case DiffInsert:
+			parts[i] = "\x1b[32m" + segment.Text + "\x1b[0m"
+		case DiffDelete:
+			parts[i] = "\x1b[31;9m" + segment.Text + "\x1b[0m"
+		default:
+			parts[i] = segment.Text
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// RenderDiffMarkdown renders segments as Markdown.
+func RenderDiffMarkdown(segments []DiffSegment) string {
+	parts := make([]string, len(segments))
+	for i, segment := range segments {
+		text := escapeMarkdown(segment.Text)
+		switch segment.Op {
+		case DiffInsert:
+			parts[i] = "**" + text + "**"
+		case DiffDelete:
+			parts[i] = "~~" + text + "~~"
+		default:
+			parts[i] = text
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// escapeMarkdown escapes characters with a meaning in Markdown.
+var escapeMarkdown = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`",
+	"[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "#", `\#`,
+).Replace
+
+// Review fetches the updated entries with their original versions and
+// content diffs, 100 at a time, and prepares them for review.
+func (s *UpdatedEntriesService) Review() ([]*EntryUpdate, error) {
+	ids, _, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []*EntryUpdate
+	for start := 0; start < len(ids); start += 100 {
+		end := start + 100
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		entries, _, err := s.client.Entries.List(&EntryListOptions{
+			IDs:                ids[start:end],
+			IncludeOriginal:    true,
+			IncludeContentDiff: true,
+		})
+		if err != nil {
+			return updates, err
+		}
+
+		for _, entry := range entries {
+			updates = append(updates, NewEntryUpdate(entry))
+		}
+	}
+
+	return updates, nil
+}
+
+// Acknowledge marks reviewed updates as read, 1000 at a time, and returns
+// the IDs the server confirmed.
+func (s *UpdatedEntriesService) Acknowledge(updates []*EntryUpdate) ([]int64, error) {
+	ids := make([]int64, len(updates))
+	for i, u := range updates {
+		ids[i] = u.ID
+	}
+
+	var acknowledged []int64
+	for start := 0; start < len(ids); start += 1000 {
+		end := start + 1000
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		marked, _, err := s.Delete(ids[start:end])
+		if err != nil {
+			return acknowledged, err
+		}
+		acknowledged = append(acknowledged, marked...)
+	}
+
+	return acknowledged, nil
+}
+
+// ParseContentDiff parses the inline-diff HTML returned in an entry's
+// content_diff into segments. Text inside <ins> and <del> elements, or
+// elements with the diff-ins and diff-del classes, is inserted or deleted;
+// other text is equal. Markup is dropped.
+func ParseContentDiff(markup string) []DiffSegment {
+	type element struct {
+		name string
+		op   DiffOp
+	}
+
+	var segments []DiffSegment
+	var stack []element
+	var text strings.Builder
+	op := DiffEqual
+
+	flush := func() {
+		if words := strings.Fields(text.String()); len(words) > 0 {
+			segments = appendSegment(segments, op, strings.Join(words, " "))
+		}
+		text.Reset()
+	}
+
+	for len(markup) > 0 {
+		lt := strings.IndexByte(markup, '<')
+		if lt < 0 {
+			text.WriteString(html.UnescapeString(markup))
+			break
+		}
+		text.WriteString(html.UnescapeString(markup[:lt]))
+		markup = markup[lt:]
+
+		if strings.HasPrefix(markup, "<!--") {
+			end := strings.Index(markup, "-->")
+			if end < 0 {
+				break
+			}
+			markup = markup[end+3:]
+			continue
+		}
+
+		gt := strings.IndexByte(markup, '>')
+		if gt < 0 {
+			break
+		}
+		tag := markup[1:gt]
+		markup = markup[gt+1:]
+
+		name, closing, selfClosing, class := parseTag(tag)
+		if name == "" {
+			continue
+		}
+
+		// Block boundaries separate words
+		if blockElements[name] {
+			text.WriteByte(' ')
+		}
+
+		if closing {
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name == name {
+					stack = stack[:i]
+					break
+				}
+			}
+		} else if !selfClosing && !voidElements[name] {
+			elementOp := op
+			if len(stack) > 0 {
+				elementOp = stack[len(stack)-1].op
+			}
+			switch {
+			case name == "ins" || hasClass(class, "diff-ins"):
+				elementOp = DiffInsert
+			case name == "del" || hasClass(class, "diff-del"):
+				elementOp = DiffDelete
+			}
+			stack = append(stack, element{name: name, op: elementOp})
+		}
+
+		next := DiffEqual
+		if len(stack) > 0 {
+			next = stack[len(stack)-1].op
+		}
+		if next != op {
+			flush()
+			op = next
+		}
+	}
+
+	flush()
+	return segments
+}
+
+// WordDiff computes a word-level diff between the text of two HTML
+// documents.
+func WordDiff(original, current string) []DiffSegment {
+	a := strings.Fields(htmlText(original))
+	b := strings.Fields(htmlText(current))
+
+	// Trim the common prefix and suffix, which is most of a typical update
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var segments []DiffSegment
+	for _, word := range a[:prefix] {
+		segments = appendSegment(segments, DiffEqual, word)
+	}
+
+	segments = append(segments, diffWords(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+
+	for _, word := range a[len(a)-suffix:] {
+		segments = appendSegment(segments, DiffEqual, word)
+	}
+
+	return mergeSegments(segments)
+}
+
+// diffWords diffs two word lists using their longest common subsequence.
+func diffWords(a, b []string) []DiffSegment {
+	var segments []DiffSegment
+
+	if len(a) == 0 || len(b) == 0 || (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, word := range a {
+			segments = appendSegment(segments, DiffDelete, word)
+		}
+		for _, word := range b {
+			segments = appendSegment(segments, DiffInsert, word)
+		}
+		return segments
+	}
+
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			segments = appendSegment(segments, DiffEqual, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			segments = appendSegment(segments, DiffDelete, a[i])
+			i++
+		default:
+			segments = appendSegment(segments, DiffInsert, b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		segments = appendSegment(segments, DiffDelete, a[i])
+	}
+	for ; j < len(b); j++ {
+		segments = appendSegment(segments, DiffInsert, b[j])
+	}
+
+	return segments
+}
+
+// appendSegment appends text to the last segment if it has the same
+// operation, or starts a new segment.
+func appendSegment(segments []DiffSegment, op DiffOp, text string) []DiffSegment {
+	if n := len(segments); n > 0 && segments[n-1].Op == op {
+		segments[n-1].Text += " " + text
+		return segments
+	}
+	return append(segments, DiffSegment{Op: op, Text: text})
+}
+
+// mergeSegments joins adjacent segments with the same operation.
+func mergeSegments(segments []DiffSegment) []DiffSegment {
+	var merged []DiffSegment
+	for _, segment := range segments {
+		merged = appendSegment(merged, segment.Op, segment.Text)
+	}
+	return merged
+}
+
+// htmlText returns the text of an HTML document.
+func htmlText(markup string) string {
+	var words []string
+	for _, segment := range ParseContentDiff(markup) {
+		words = append(words, segment.Text)
+	}
+	return strings.Join(words, " ")
+}
+
+// parseTag parses the inside of a tag into its lower-cased name, whether it
+// is a closing or self-closing tag, and its class attribute.
+func parseTag(tag string) (name string, closing, selfClosing bool, class string) {
+	if strings.HasPrefix(tag, "/") {
+		closing = true
+		tag = tag[1:]
+	}
+	if strings.HasSuffix(tag, "/") {
+		selfClosing = true
+		tag = tag[:len(tag)-1]
+	}
+
+	end := strings.IndexFunc(tag, func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '\r' })
+	if end < 0 {
+		end = len(tag)
+	}
+	name = strings.ToLower(tag[:end])
+	if name == "" || name[0] == '!' || name[0] == '?' {
+		return "", false, false, ""
+	}
+
+	attrs := tag[end:]
+	if i := strings.Index(strings.ToLower(attrs), "class="); i >= 0 {
+		value := attrs[i+len("class="):]
+		if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+			if j := strings.IndexByte(value[1:], value[0]); j >= 0 {
+				class = value[1 : j+1]
+			}
+		} else if fields := strings.Fields(value); len(fields) > 0 {
+			class = fields[0]
+		}
+	}
+
+	return name, closing, selfClosing, class
+}
+
+// hasClass reports whether a class attribute contains name.
+func hasClass(class, name string) bool {
+	for _, c := range strings.Fields(class) {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// blockElements are elements that separate words.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"br": true, "dd": true, "div": true, "dl": true, "dt": true,
+	"figcaption": true, "figure": true, "footer": true, "h1": true,
+	"h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "ol": true, "p": true,
+	"pre": true, "section": true, "table": true, "td": true, "th": true,
+	"tr": true, "ul": true,
+}
+
+// voidElements are elements that have no closing tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
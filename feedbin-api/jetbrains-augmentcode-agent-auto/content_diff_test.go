@@ -0,0 +1,117 @@
+package feedbin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseContentDiff(t *testing.T) {
+	markup := `<div class="inline-diff"><p>Brought to you by <del>two</del><ins>three</ins> sponsors:</p>` +
+		`<p class="diff-ins"><strong>Update:</strong> new &amp; improved.</p><p>Thanks</p></div>`
+
+	want := []DiffSegment{
+		{Op: DiffEqual, Text: "Brought to you by"},
+		{Op: DiffDelete, Text: "two"},
+		{Op: DiffInsert, Text: "three"},
+		{Op: DiffEqual, Text: "sponsors:"},
+		{Op: DiffInsert, Text: "Update: new & improved."},
+		{Op: DiffEqual, Text: "Thanks"},
+	}
+
+	if got := ParseContentDiff(markup); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseContentDiff = %v, want %v", got, want)
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	got := WordDiff("<p>The quick brown fox jumps</p>", "<p>The quick red fox leaps</p>")
+	want := []DiffSegment{
+		{Op: DiffEqual, Text: "The quick"},
+		{Op: DiffDelete, Text: "brown"},
+		{Op: DiffInsert, Text: "red"},
+		{Op: DiffEqual, Text: "fox"},
+		{Op: DiffDelete, Text: "jumps"},
+		{Op: DiffInsert, Text: "leaps"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WordDiff = %v, want %v", got, want)
+	}
+}
+
+func TestEntryUpdate_Render(t *testing.T) {
+	u := NewEntryUpdate(&Entry{
+		ID:       1,
+		Title:    "New title",
+		Content:  "<p>One two four</p>",
+		Original: &Entry{Title: "Old title", Content: "<p>One two three</p>"},
+	})
+
+	if got, want := RenderDiffMarkdown(u.Segments()), "One two ~~three~~ **four**"; got != want {
+		t.Errorf("RenderDiffMarkdown = %q, want %q", got, want)
+	}
+
+	if got := u.Markdown(); !strings.HasPrefix(got, "### ~~Old~~ **New** title\n") {
+		t.Errorf("Markdown = %q", got)
+	}
+
+	if got := u.ANSI(); !strings.Contains(got, "\x1b[31;9mthree\x1b[0m \x1b[32mfour\x1b[0m") {
+		t.Errorf("ANSI = %q", got)
+	}
+
+	data, err := json.Marshal(u)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	if !strings.Contains(string(data), `"word_diff":[{"op":"equal","text":"One two"}`) {
+		t.Errorf("JSON = %s", data)
+	}
+}
+
+func TestUpdatedEntriesService_ReviewAndAcknowledge(t *testing.T) {
+	var acknowledged []int64
+
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/updated_entries.json":
+			w.Write([]byte(`[7]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/entries.json":
+			if r.URL.Query().Get("include_original") != "true" || r.URL.Query().Get("include_content_diff") != "true" {
+				t.Errorf("Expected original and content diff to be requested, got '%s'", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"id": 7, "title": "T", "content": "a c", "original": {"title": "T", "content": "a b"}, "content_diff": "a <del>b</del><ins>c</ins>"}]`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/updated_entries.json":
+			var body UpdatedEntriesRequest
+			json.NewDecoder(r.Body).Decode(&body)
+			acknowledged = body.UpdatedEntries
+			json.NewEncoder(w).Encode(body.UpdatedEntries)
+		default:
+			t.Errorf("Unexpected %s request to '%s'", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("username", "password")
+	client.SetBaseURL(server.URL)
+
+	updates, err := client.UpdatedEntries.Review()
+	if err != nil {
+		t.Fatalf("UpdatedEntries.Review returned error: %v", err)
+	}
+	if len(updates) != 1 || len(updates[0].ContentDiff) != 3 || len(updates[0].WordDiff) != 3 {
+		t.Fatalf("UpdatedEntries.Review = %+v", updates)
+	}
+
+	ids, err := client.UpdatedEntries.Acknowledge(updates)
+	if err != nil {
+		t.Fatalf("UpdatedEntries.Acknowledge returned error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{7}) || !reflect.DeepEqual(acknowledged, []int64{7}) {
+		t.Errorf("UpdatedEntries.Acknowledge = %v, server got %v", ids, acknowledged)
+	}
+}
@@ -0,0 +1,391 @@
+package feedbin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DryRunPlan collects the operations planned by a client in dry-run mode. In
+// dry-run mode requests other than GET are not sent; they are added to the
+// plan and the caller gets a simulated result computed from the current
+// server state. The plan can be printed for review before a real run. It is
+// safe for concurrent use.
+type DryRunPlan struct {
+	mu         sync.Mutex
+	operations []*PlannedOperation
+}
+
+// NewDryRunPlan returns an empty plan.
+func NewDryRunPlan() *DryRunPlan {
+	return &DryRunPlan{}
+}
+
+// PlannedOperation is a request that was not sent because of dry-run mode.
+type PlannedOperation struct {
+	Method string
+	Path   string
+	Body   string
+
+	// Summary describes the effect of the request, for example
+	// "mark 3 entries as read".
+	Summary string
+
+	// Result is the simulated result returned to the caller.
+	Result interface{}
+}
+
+// String formats the operation on one line, followed by its body if any.
+func (o *PlannedOperation) String() string {
+	s := fmt.Sprintf("%s %s", o.Method, o.Path)
+	if o.Summary != "" {
+		s += ": " + o.Summary
+	}
+	if o.Body != "" {
+		s += "\n    " + o.Body
+	}
+	return s
+}
+
+// Operations returns the planned operations in the order they were made.
+func (p *DryRunPlan) Operations() []*PlannedOperation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*PlannedOperation(nil), p.operations...)
+}
+
+// Len returns the number of planned operations.
+func (p *DryRunPlan) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.operations)
+}
+
+// Reset removes all planned operations.
+func (p *DryRunPlan) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.operations = nil
+}
+
+// String formats the plan for review, one numbered operation per line.
+func (p *DryRunPlan) String() string {
+	operations := p.Operations()
+	if len(operations) == 0 {
+		return "Dry run: no operations planned\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Dry run: %d planned %s\n", len(operations), pluralize(len(operations), "operation", "operations"))
+	for i, op := range operations {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, op)
+	}
+	return b.String()
+}
+
+// add appends an operation to the plan.
+func (p *DryRunPlan) add(op *PlannedOperation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.operations = append(p.operations, op)
+}
+
+// SetDryRun puts the client in dry-run mode, adding the requests it would
+// send to plan. A nil plan turns dry-run mode off.
+func (c *Client) SetDryRun(plan *DryRunPlan) {
+	c.dryRun = plan
+}
+
+// DryRun returns the plan of a client in dry-run mode, or nil.
+func (c *Client) DryRun() *DryRunPlan {
+	return c.dryRun
+}
+
+// WithDryRun returns a copy of the client in dry-run mode, for dry-running
+// single calls:
+//
+//	client.WithDryRun(plan).UnreadEntries.Delete(ids)
+//
+// The copy shares the HTTP client, credentials and settings of c.
+func (c *Client) WithDryRun(plan *DryRunPlan) *Client {
+	dc := c.clone()
+	dc.dryRun = plan
+	return dc
+}
+
+// simulate records a request in the dry-run plan instead of sending it and
+// stores a simulated result in v.
+func (c *Client) simulate(req *http.Request, v interface{}) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, strings.TrimSuffix(c.baseURL.Path, "/"))
+	op := &PlannedOperation{
+		Method: req.Method,
+		Path:   path,
+		Body:   strings.TrimSpace(string(body)),
+	}
+
+	result, err := c.simulateResult(op, body)
+	if err != nil {
+		return nil, err
+	}
+	op.Result = result
+	c.dryRun.add(op)
+
+	resp := &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"X-Feedbin-Dry-Run": []string{"true"}},
+		Body:       http.NoBody,
+		Request:    req,
+	}
+
+	if v != nil && result != nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return resp, err
+		}
+		if w, ok := v.(io.Writer); ok {
+			w.Write(data)
+		} else if err := json.Unmarshal(data, v); err != nil {
+			return resp, err
+		}
+	}
+
+	return resp, nil
+}
+
+// simulateResult computes the result a mutating request would have from the
+// current server state and describes the operation.
+func (c *Client) simulateResult(op *PlannedOperation, body []byte) (interface{}, error) {
+	endpoint := EndpointTemplate(op.Path)
+
+	switch {
+	case endpoint == "/v2/unread_entries.json" && op.Method == http.MethodPost:
+		var r UnreadEntriesRequest
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		return c.simulateIDs(op, "/v2/unread_entries.json", r.UnreadEntries, false, "mark %d %s as unread")
+
+	case endpoint == "/v2/unread_entries.json" || endpoint == "/v2/unread_entries/delete.json":
+		var r UnreadEntriesRequest
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		return c.simulateIDs(op, "/v2/unread_entries.json", r.UnreadEntries, true, "mark %d %s as read")
+
+	case endpoint == "/v2/starred_entries.json" && op.Method == http.MethodPost:
+		var r StarredEntriesRequest
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		return c.simulateIDs(op, "/v2/starred_entries.json", r.StarredEntries, false, "star %d %s")
+
+	case endpoint == "/v2/starred_entries.json" || endpoint == "/v2/starred_entries/delete.json":
+		var r StarredEntriesRequest
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		return c.simulateIDs(op, "/v2/starred_entries.json", r.StarredEntries, true, "unstar %d %s")
+
+	case endpoint == "/v2/updated_entries.json" || endpoint == "/v2/updated_entries/delete.json":
+		var r UpdatedEntriesRequest
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		return c.simulateIDs(op, "/v2/updated_entries.json", r.UpdatedEntries, true, "mark %d updated %s as read")
+
+	case endpoint == "/v2/tags.json" && op.Method == http.MethodPost:
+		var r RenameTagOptions
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		return c.simulateTags(op, r.OldName, r.NewName)
+
+	case endpoint == "/v2/tags.json" && op.Method == http.MethodDelete:
+		var r DeleteTagOptions
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		return c.simulateTags(op, r.Name, "")
+
+	case endpoint == "/v2/subscriptions.json":
+		var r CreateSubscriptionOptions
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		op.Summary = fmt.Sprintf("subscribe to %s", r.FeedURL)
+		return &Subscription{FeedURL: r.FeedURL, Title: r.FeedURL}, nil
+
+	case endpoint == "/v2/subscriptions/:id.json" || endpoint == "/v2/subscriptions/:id/update.json":
+		subscription := new(Subscription)
+		if err := c.getCurrent(strings.Replace(op.Path, "/update.json", ".json", 1), subscription); err != nil {
+			return nil, err
+		}
+		if op.Method == http.MethodDelete {
+			op.Summary = fmt.Sprintf("unsubscribe from %q", subscription.Title)
+			return nil, nil
+		}
+		var r UpdateSubscriptionOptions
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		op.Summary = fmt.Sprintf("rename subscription %q to %q", subscription.Title, r.Title)
+		subscription.Title = r.Title
+		return subscription, nil
+
+	case endpoint == "/v2/taggings.json":
+		var r CreateTaggingOptions
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		op.Summary = fmt.Sprintf("tag feed %d with %q", r.FeedID, r.Name)
+		return &Tagging{FeedID: r.FeedID, Name: r.Name}, nil
+
+	case endpoint == "/v2/taggings/:id.json":
+		tagging := new(Tagging)
+		if err := c.getCurrent(op.Path, tagging); err != nil {
+			return nil, err
+		}
+		op.Summary = fmt.Sprintf("remove tag %q from feed %d", tagging.Name, tagging.FeedID)
+		return nil, nil
+
+	case endpoint == "/v2/saved_searches.json" || endpoint == "/v2/saved_searches/:id.json":
+		var r CreateSavedSearchOptions
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		switch op.Method {
+		case http.MethodDelete:
+			op.Summary = "delete saved search"
+			return nil, nil
+		case http.MethodPost:
+			op.Summary = fmt.Sprintf("create saved search %q", r.Name)
+		default:
+			op.Summary = fmt.Sprintf("update saved search %q", r.Name)
+		}
+		return &SavedSearch{Name: r.Name, Query: r.Query}, nil
+
+	case endpoint == "/v2/pages.json":
+		var r CreatePageOptions
+		if err := decodeDryRunBody(body, &r); err != nil {
+			return nil, err
+		}
+		op.Summary = fmt.Sprintf("save page %s", r.URL)
+		return &Entry{URL: r.URL, Title: r.Title}, nil
+
+	case endpoint == "/v2/imports.json":
+		op.Summary = "import OPML file"
+		return &Import{}, nil
+	}
+
+	return nil, nil
+}
+
+// decodeDryRunBody decodes the JSON body of a planned request into v. An
+// empty body leaves v unchanged.
+func decodeDryRunBody(body []byte, v interface{}) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("dry run: invalid request body: %w", err)
+	}
+
+	return nil
+}
+
+// simulateIDs returns the entry IDs whose state would change: the requested
+// IDs that are in the current list if remove is set, or that are not in it
+// otherwise.
+func (c *Client) simulateIDs(op *PlannedOperation, listPath string, ids []int64, remove bool, summary string) ([]int64, error) {
+	var current []int64
+	if err := c.getCurrent(listPath, &current); err != nil {
+		return nil, err
+	}
+
+	inList := make(map[int64]bool, len(current))
+	for _, id := range current {
+		inList[id] = true
+	}
+
+	changed := []int64{}
+	for _, id := range ids {
+		if inList[id] == remove {
+			changed = append(changed, id)
+		}
+	}
+
+	op.Summary = fmt.Sprintf(summary, len(changed), pluralize(len(changed), "entry", "entries"))
+	if unchanged := len(ids) - len(changed); unchanged > 0 {
+		op.Summary += fmt.Sprintf(" (%d unchanged)", unchanged)
+	}
+
+	return changed, nil
+}
+
+// simulateTags returns the taggings after renaming oldName to newName, or
+// after deleting oldName if newName is empty.
+func (c *Client) simulateTags(op *PlannedOperation, oldName, newName string) ([]*Tagging, error) {
+	var taggings []*Tagging
+	if err := c.getCurrent("/v2/taggings.json", &taggings); err != nil {
+		return nil, err
+	}
+
+	result := []*Tagging{}
+	affected := 0
+	for _, tagging := range taggings {
+		if tagging.Name == oldName {
+			affected++
+			if newName == "" {
+				continue
+			}
+			tagging.Name = newName
+		}
+		result = append(result, tagging)
+	}
+
+	feeds := pluralize(affected, "feed", "feeds")
+	if newName == "" {
+		op.Summary = fmt.Sprintf("delete tag %q from %d %s", oldName, affected, feeds)
+	} else {
+		op.Summary = fmt.Sprintf("rename tag %q to %q on %d %s", oldName, newName, affected, feeds)
+	}
+
+	return result, nil
+}
+
+// getCurrent fetches the current state of a resource for a simulation.
+func (c *Client) getCurrent(path string, v interface{}) error {
+	req, err := c.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.Do(req, v)
+	return err
+}
+
+// pluralize returns singular if n is 1 and plural otherwise.
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
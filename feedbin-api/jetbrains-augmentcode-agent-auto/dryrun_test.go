@@ -0,0 +1,129 @@
+package feedbin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newDryRunServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only reads may reach the server in dry-run mode
+		if r.Method != http.MethodGet {
+			t.Errorf("Unexpected %s request to '%s' in dry-run mode", r.Method, r.URL.Path)
+		}
+
+		switch r.URL.Path {
+		case "/v2/unread_entries.json":
+			w.Write([]byte(`[1, 2, 3]`))
+		case "/v2/taggings.json":
+			w.Write([]byte(`[{"id": 1, "feed_id": 10, "name": "Go"}, {"id": 2, "feed_id": 20, "name": "Go"}, {"id": 3, "feed_id": 30, "name": "News"}]`))
+		case "/v2/subscriptions/5.json":
+			w.Write([]byte(`{"id": 5, "feed_id": 50, "title": "Example"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestClient_DryRun(t *testing.T) {
+	server := newDryRunServer(t)
+	defer server.Close()
+
+	client := NewClient("username", "password")
+	client.SetBaseURL(server.URL)
+
+	plan := NewDryRunPlan()
+	client.SetDryRun(plan)
+
+	// Marking as read only changes entries that are currently unread
+	ids, resp, err := client.UnreadEntries.Delete([]int64{2, 3, 4})
+	if err != nil {
+		t.Fatalf("UnreadEntries.Delete returned error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{2, 3}) {
+		t.Errorf("UnreadEntries.Delete = %v, want %v", ids, []int64{2, 3})
+	}
+	if resp.Header.Get("X-Feedbin-Dry-Run") != "true" {
+		t.Error("Expected a simulated response")
+	}
+
+	taggings, _, err := client.Tags.Rename(&RenameTagOptions{OldName: "Go", NewName: "Golang"})
+	if err != nil {
+		t.Fatalf("Tags.Rename returned error: %v", err)
+	}
+	if len(taggings) != 3 || taggings[0].Name != "Golang" || taggings[2].Name != "News" {
+		t.Errorf("Tags.Rename = %v", taggings)
+	}
+
+	if _, err := client.Subscriptions.Delete(5); err != nil {
+		t.Fatalf("Subscriptions.Delete returned error: %v", err)
+	}
+
+	// Deleting something that does not exist fails as it would for real
+	if _, err := client.Taggings.Delete(99); err == nil {
+		t.Error("Taggings.Delete of a missing tagging should have returned an error")
+	}
+
+	if plan.Len() != 3 {
+		t.Fatalf("Plan has %d operations, want %d", plan.Len(), 3)
+	}
+
+	out := plan.String()
+	for _, want := range []string{
+		"Dry run: 3 planned operations",
+		"1. DELETE /v2/unread_entries.json: mark 2 entries as read (1 unchanged)",
+		`{"unread_entries":[2,3,4]}`,
+		`2. POST /v2/tags.json: rename tag "Go" to "Golang" on 2 feeds`,
+		`3. DELETE /v2/subscriptions/5.json: unsubscribe from "Example"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Plan output missing %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestClient_WithDryRun(t *testing.T) {
+	server := newDryRunServer(t)
+	defer server.Close()
+
+	client := NewClient("username", "password")
+	client.SetBaseURL(server.URL)
+
+	plan := NewDryRunPlan()
+	if _, _, err := client.WithDryRun(plan).Tags.Delete(&DeleteTagOptions{Name: "News"}); err != nil {
+		t.Fatalf("Tags.Delete returned error: %v", err)
+	}
+
+	if plan.Len() != 1 {
+		t.Errorf("Plan has %d operations, want %d", plan.Len(), 1)
+	}
+	if client.DryRun() != nil {
+		t.Error("WithDryRun should not change the original client")
+	}
+}
+
+func TestClient_DryRunInvalidBody(t *testing.T) {
+	server := newDryRunServer(t)
+	defer server.Close()
+
+	client := NewClient("username", "password")
+	client.SetBaseURL(server.URL)
+
+	plan := NewDryRunPlan()
+	client.SetDryRun(plan)
+
+	req, err := client.NewRequest(http.MethodDelete, "/v2/unread_entries.json", map[string]string{"unread_entries": "1,2"})
+	if err != nil {
+		t.Fatalf("NewRequest returned error: %v", err)
+	}
+
+	if _, err := client.Do(req, nil); err == nil || !strings.Contains(err.Error(), "invalid request body") {
+		t.Errorf("Do returned %v, want a body decoding error", err)
+	}
+	if plan.Len() != 0 {
+		t.Errorf("Plan has %d operations, want none", plan.Len())
+	}
+}
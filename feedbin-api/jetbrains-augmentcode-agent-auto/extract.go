@@ -1,18 +1,31 @@
 package feedbin
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
+// DefaultExtractURL is the base URL of the full content extraction service.
+const DefaultExtractURL = "https://extract.feedbin.com"
+
+// ErrExtractSecretRequired is returned when the extract service has no secret.
+var ErrExtractSecretRequired = errors.New("extract service requires a username and secret")
+
 // ExtractService handles communication with the full content extraction service.
+// Requests share the HTTP client, timeout and user agent of the main client.
 type ExtractService struct {
 	client   *Client
+	baseURL  *url.URL
 	username string
 	secret   string
 }
@@ -22,9 +35,28 @@ func (s *ExtractService) SetSecret(secret string) {
 	s.secret = secret
 }
 
-// NewExtractService creates a new extract service.
+// SetCredentials sets the username and secret key for the extract service.
+func (s *ExtractService) SetCredentials(username, secret string) {
+	s.username = username
+	s.secret = secret
+}
+
+// SetBaseURL sets the base URL of the extract service, for example to use a
+// self-hosted instance.
+func (s *ExtractService) SetBaseURL(urlStr string) error {
+	baseURL, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+	s.baseURL = baseURL
+	return nil
+}
+
+// NewExtractService creates a new extract service that is not attached to a
+// Feedbin API client. It uses its own HTTP client with the default timeout.
 func NewExtractService(username, secret string) *ExtractService {
 	return &ExtractService{
+		client:   NewClient(username, ""),
 		username: username,
 		secret:   secret,
 	}
@@ -48,44 +80,74 @@ type ExtractedArticle struct {
 	RenderedPages int    `json:"rendered_pages"`
 }
 
-// Extract extracts the full content of a webpage.
-func (s *ExtractService) Extract(pageURL string) (*ExtractedArticle, error) {
+// SignedURL returns the signed extraction URL for a webpage.
+func (s *ExtractService) SignedURL(pageURL string) (string, error) {
+	if s.username == "" || s.secret == "" {
+		return "", ErrExtractSecretRequired
+	}
+
 	// Create HMAC-SHA1 signature
 	h := hmac.New(sha1.New, []byte(s.secret))
 	h.Write([]byte(pageURL))
 	signature := hex.EncodeToString(h.Sum(nil))
 
-	// Base64 encode the URL
-	base64URL := base64.URLEncoding.EncodeToString([]byte(pageURL))
+	baseURL := s.baseURL
+	if baseURL == nil {
+		baseURL, _ = url.Parse(DefaultExtractURL)
+	}
+
+	// The parser path is joined onto the base path so self-hosted instances
+	// under a path prefix work. The username is escaped once, in RawPath.
+	u := *baseURL
+	u.Path = strings.TrimSuffix(baseURL.Path, "/") + fmt.Sprintf("/parser/%s/%s", s.username, signature)
+	u.RawPath = strings.TrimSuffix(baseURL.EscapedPath(), "/") + fmt.Sprintf("/parser/%s/%s", url.PathEscape(s.username), signature)
+	u.RawQuery = "base64_url=" + base64.URLEncoding.EncodeToString([]byte(pageURL))
+	u.Fragment = ""
 
-	// Create the request URL
-	extractURL := fmt.Sprintf("https://extract.feedbin.com/parser/%s/%s?base64_url=%s",
-		s.username, signature, base64URL)
+	return u.String(), nil
+}
+
+// Extract extracts the full content of a webpage.
+func (s *ExtractService) Extract(pageURL string) (*ExtractedArticle, error) {
+	return s.ExtractContext(context.Background(), pageURL)
+}
 
-	// Create a new HTTP client
-	client := &http.Client{}
+// ExtractContext extracts the full content of a webpage. The request is
+// cancelled when ctx is done.
+func (s *ExtractService) ExtractContext(ctx context.Context, pageURL string) (*ExtractedArticle, error) {
+	extractURL, err := s.SignedURL(pageURL)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create the request
-	req, err := http.NewRequest(http.MethodGet, extractURL, nil)
+	// The extract service is authenticated by the signature, so the API
+	// credentials are not sent
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, extractURL, nil)
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("User-Agent", s.client.userAgent)
 
-	// Send the request
-	resp, err := client.Do(req)
+	start := time.Now()
+	resp, err := s.client.client.Do(req)
 	if err != nil {
+		s.client.observe(req, nil, start, 0, 1, err)
 		return nil, err
 	}
-	defer resp.Body.Close()
+	body := &countingReader{ReadCloser: resp.Body}
+	defer body.Close()
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("extract service returned status code %d", resp.StatusCode)
+		err = fmt.Errorf("extract service returned status code %d", resp.StatusCode)
+		s.client.observe(req, resp, start, body.n, 1, err)
+		return nil, err
 	}
 
 	// Decode the response
 	var article ExtractedArticle
-	err = json.NewDecoder(resp.Body).Decode(&article)
+	err = json.NewDecoder(body).Decode(&article)
+	s.client.observe(req, resp, start, body.n, 1, err)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,194 @@
+package feedbin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExtractService_Extract(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Check the request path
+		if !strings.HasPrefix(r.URL.Path, "/parser/username/") {
+			t.Errorf("Expected request to '/parser/username/...', got '%s'", r.URL.Path)
+		}
+
+		// Check the user agent
+		if got := r.Header.Get("User-Agent"); got != "test-agent" {
+			t.Errorf("Expected User-Agent to be 'test-agent', got '%s'", got)
+		}
+
+		// The API credentials must not be sent to the extract service
+		if _, _, ok := r.BasicAuth(); ok {
+			t.Error("Expected no Basic Auth header")
+		}
+
+		pageURL, err := base64.URLEncoding.DecodeString(r.URL.Query().Get("base64_url"))
+		if err != nil {
+			t.Errorf("Invalid base64_url: %v", err)
+		}
+
+		json.NewEncoder(w).Encode(&ExtractedArticle{Title: "Title", URL: string(pageURL)})
+	}))
+	defer server.Close()
+
+	// Create a client using the test server URL
+	client := NewClient("username", "password")
+	client.SetUserAgent("test-agent")
+	client.Extract.SetSecret("secret")
+	if err := client.Extract.SetBaseURL(server.URL); err != nil {
+		t.Fatalf("SetBaseURL returned error: %v", err)
+	}
+
+	article, err := client.Extract.Extract("https://example.com/article")
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+	if article.URL != "https://example.com/article" {
+		t.Errorf("Extract URL = %v, want %v", article.URL, "https://example.com/article")
+	}
+}
+
+func TestExtractService_ExtractNoSecret(t *testing.T) {
+	client := NewClient("username", "password")
+
+	_, err := client.Extract.Extract("https://example.com/article")
+	if err != ErrExtractSecretRequired {
+		t.Errorf("Extract error = %v, want %v", err, ErrExtractSecretRequired)
+	}
+}
+
+func TestExtractService_SignedURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		username string
+		prefix   string
+	}{
+		{DefaultExtractURL, "username", "https://extract.feedbin.com/parser/username/"},
+		{DefaultExtractURL, "a b", "https://extract.feedbin.com/parser/a%20b/"},
+		{DefaultExtractURL, "a/b%c", "https://extract.feedbin.com/parser/a%2Fb%25c/"},
+		{"https://example.com/extract", "username", "https://example.com/extract/parser/username/"},
+		{"https://example.com/extract/", "a b", "https://example.com/extract/parser/a%20b/"},
+	}
+
+	for _, tt := range tests {
+		service := NewExtractService(tt.username, "secret")
+		if err := service.SetBaseURL(tt.baseURL); err != nil {
+			t.Fatalf("SetBaseURL returned error: %v", err)
+		}
+
+		got, err := service.SignedURL("https://example.com/article")
+		if err != nil {
+			t.Fatalf("SignedURL returned error: %v", err)
+		}
+
+		if !strings.HasPrefix(got, tt.prefix) || !strings.HasSuffix(got, "?base64_url=aHR0cHM6Ly9leGFtcGxlLmNvbS9hcnRpY2xl") {
+			t.Errorf("SignedURL with base %q and username %q = %v, want prefix %v", tt.baseURL, tt.username, got, tt.prefix)
+		}
+	}
+}
+
+func TestExtractService_Prefetch(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight, requests int
+
+	// Create a test server that records how many extractions run at once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		pageURL, _ := base64.URLEncoding.DecodeString(r.URL.Query().Get("base64_url"))
+		if strings.Contains(string(pageURL), "broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(&ExtractedArticle{URL: string(pageURL)})
+	}))
+	defer server.Close()
+
+	client := NewClient("username", "password")
+	client.Extract.SetSecret("secret")
+	client.Extract.SetBaseURL(server.URL)
+
+	var entries []*Entry
+	for i := 1; i <= 6; i++ {
+		entries = append(entries, &Entry{ID: int64(i), URL: fmt.Sprintf("https://example.com/%d", i)})
+	}
+	entries = append(entries, &Entry{ID: 7, URL: "https://example.com/broken"})
+
+	store := NewMemoryContentStore()
+	store.Put(1, &ExtractedArticle{URL: "https://example.com/1"})
+
+	result, err := client.Extract.Prefetch(context.Background(), entries, store, &PrefetchOptions{
+		Concurrency: 4,
+		PerHost:     2,
+		HostDelay:   -1,
+	})
+	if err != nil {
+		t.Fatalf("Prefetch returned error: %v", err)
+	}
+
+	if result.Extracted != 5 {
+		t.Errorf("Prefetch Extracted = %v, want %v", result.Extracted, 5)
+	}
+	if result.Skipped != 1 {
+		t.Errorf("Prefetch Skipped = %v, want %v", result.Skipped, 1)
+	}
+	if _, ok := result.Errors[7]; !ok || len(result.Errors) != 1 {
+		t.Errorf("Prefetch Errors = %v, want an error for entry 7", result.Errors)
+	}
+	if requests != 6 {
+		t.Errorf("Prefetch made %v requests, want %v", requests, 6)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("Prefetch ran %v extractions on one host at once, want at most %v", maxInFlight, 2)
+	}
+
+	article, _ := store.Get(4)
+	if article == nil || article.URL != "https://example.com/4" {
+		t.Errorf("Stored article for entry 4 = %v", article)
+	}
+}
+
+func TestFileContentStore(t *testing.T) {
+	store, err := NewFileContentStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileContentStore returned error: %v", err)
+	}
+
+	article, err := store.Get(1)
+	if err != nil || article != nil {
+		t.Errorf("Get on empty store = %v, %v, want nil, nil", article, err)
+	}
+
+	if err := store.Put(1, &ExtractedArticle{Title: "Title"}); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	article, err = store.Get(1)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if article == nil || article.Title != "Title" {
+		t.Errorf("Get = %v, want article with title 'Title'", article)
+	}
+}
@@ -0,0 +1,525 @@
+package feedbin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultImageConcurrency is the default number of concurrent image
+	// downloads.
+	DefaultImageConcurrency = 4
+
+	// DefaultMaxImageBytes is the default size limit of a single image.
+	DefaultMaxImageBytes = 10 << 20
+
+	// imageIndexFile is the name of the image cache index in its directory.
+	imageIndexFile = "index.json"
+)
+
+// ErrImageTooLarge is returned for images larger than the size limit.
+var ErrImageTooLarge = errors.New("image exceeds the size limit")
+
+// ImageRewrite selects how ImageCache.RewriteHTML points images at the cache.
+type ImageRewrite int
+
+const (
+	// ImageRewriteFile points images at file:// URLs of the cached files.
+	ImageRewriteFile ImageRewrite = iota
+
+	// ImageRewriteDataURI inlines images as data: URIs.
+	ImageRewriteDataURI
+)
+
+// ImageCache downloads entry images into a content-addressed directory so
+// entries can be shown offline. Images are stored once per distinct content,
+// named by their SHA-256 hash. When the total size exceeds the limit, the
+// least recently used images are evicted. It is safe for concurrent use.
+type ImageCache struct {
+	client   *Client
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	index imageIndex
+}
+
+// imageIndex is the persisted state of an image cache.
+type imageIndex struct {
+	// URLs maps image URLs to the hash of their content.
+	URLs map[string]string `json:"urls"`
+
+	// Blobs holds the stored files by hash.
+	Blobs map[string]*imageBlob `json:"blobs"`
+}
+
+// imageBlob is a stored image file.
+type imageBlob struct {
+	File        string    `json:"file"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	LastUsed    time.Time `json:"last_used"`
+}
+
+// ImagePrefetchOptions specifies the optional parameters to the
+// ImageCache.Prefetch method.
+type ImagePrefetchOptions struct {
+	// Concurrency is the maximum number of downloads in flight.
+	Concurrency int
+
+	// MaxImageBytes is the size limit of a single image.
+	MaxImageBytes int64
+}
+
+// ImagePrefetchResult summarizes an image prefetch run.
+type ImagePrefetchResult struct {
+	Downloaded int
+	Cached     int
+	Evicted    int
+	Errors     map[string]error
+}
+
+// NewImageCache returns an image cache in dir, creating it if needed, that
+// downloads with the client's HTTP client and user agent. A maxBytes of zero
+// or less disables eviction.
+func NewImageCache(client *Client, dir string, maxBytes int64) (*ImageCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	c := &ImageCache{
+		client:   client,
+		dir:      dir,
+		maxBytes: maxBytes,
+		index: imageIndex{
+			URLs:  make(map[string]string),
+			Blobs: make(map[string]*imageBlob),
+		},
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, imageIndexFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &c.index); err != nil {
+			return nil, fmt.Errorf("error reading image cache index: %w", err)
+		}
+		if c.index.URLs == nil {
+			c.index.URLs = make(map[string]string)
+		}
+		if c.index.Blobs == nil {
+			c.index.Blobs = make(map[string]*imageBlob)
+		}
+	}
+
+	return c, nil
+}
+
+// imgPattern matches img tags.
+var imgPattern = regexp.MustCompile(`(?is)<img\b[^>]*>`)
+
+// srcPattern matches the src attribute of a tag.
+var srcPattern = regexp.MustCompile(`(?is)(\ssrc\s*=\s*)("[^"]*"|'[^']*'|[^\s>]+)`)
+
+// srcsetPattern matches the srcset attribute of a tag.
+var srcsetPattern = regexp.MustCompile(`(?is)\ssrcset\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+
+// EntryImageURLs returns the absolute URLs of the images of an entry: the
+// extended mode images and the images in its content, without duplicates.
+func EntryImageURLs(entry *Entry) []string {
+	seen := make(map[string]bool)
+	var urls []string
+
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	if entry.Images != nil {
+		if entry.Images.Size1 != nil {
+			add(entry.Images.Size1.CDNURL)
+		}
+		add(entry.Images.OriginalURL)
+	}
+
+	for _, tag := range imgPattern.FindAllString(entry.Content, -1) {
+		if m := srcPattern.FindStringSubmatch(tag); m != nil {
+			add(resolveImageURL(entry.URL, attributeValue(m[2])))
+		}
+	}
+
+	return urls
+}
+
+// Prefetch downloads the images of entries that are not cached yet, then
+// evicts images if the cache is over its size limit. Failed downloads are
+// reported in the result; the returned error is only set when ctx is done
+// or the index cannot be saved.
+func (c *ImageCache) Prefetch(ctx context.Context, entries []*Entry, opts *ImagePrefetchOptions) (*ImagePrefetchResult, error) {
+	if opts == nil {
+		opts = &ImagePrefetchOptions{}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultImageConcurrency
+	}
+
+	result := &ImagePrefetchResult{Errors: make(map[string]error)}
+
+	var urls []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		for _, u := range EntryImageURLs(entry) {
+			if seen[u] {
+				continue
+			}
+			seen[u] = true
+
+			if _, ok := c.lookup(u); ok {
+				result.Cached++
+				continue
+			}
+			urls = append(urls, u)
+		}
+	}
+
+	var mu sync.Mutex
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range jobs {
+				err := c.download(ctx, u, opts.MaxImageBytes)
+
+				mu.Lock()
+				if err != nil {
+					result.Errors[u] = err
+				} else {
+					result.Downloaded++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	err := ctx.Err()
+	for _, u := range urls {
+		if err != nil {
+			break
+		}
+		select {
+		case jobs <- u:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	evicted, evictErr := c.Evict()
+	result.Evicted = evicted
+	if err == nil {
+		err = evictErr
+	}
+
+	return result, err
+}
+
+// Path returns the local file of a cached image.
+func (c *ImageCache) Path(imageURL string) (string, bool) {
+	blob, ok := c.lookup(imageURL)
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(c.dir, blob.File), true
+}
+
+// DataURI returns a cached image as a data: URI.
+func (c *ImageCache) DataURI(imageURL string) (string, bool, error) {
+	blob, ok := c.lookup(imageURL)
+	if !ok {
+		return "", false, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.dir, blob.File))
+	if err != nil {
+		return "", false, err
+	}
+
+	return "data:" + blob.ContentType + ";base64," + base64.StdEncoding.EncodeToString(data), true, nil
+}
+
+// RewriteHTML points the img tags of markup whose images are cached at the
+// cache. Relative image URLs are resolved against baseURL. The srcset of
+// rewritten images is removed, since it refers to remote images.
+func (c *ImageCache) RewriteHTML(markup, baseURL string, mode ImageRewrite) string {
+	return imgPattern.ReplaceAllStringFunc(markup, func(tag string) string {
+		m := srcPattern.FindStringSubmatch(tag)
+		if m == nil {
+			return tag
+		}
+
+		imageURL := resolveImageURL(baseURL, attributeValue(m[2]))
+
+		var local string
+		switch mode {
+		case ImageRewriteDataURI:
+			uri, ok, err := c.DataURI(imageURL)
+			if err != nil || !ok {
+				return tag
+			}
+			local = uri
+		default:
+			path, ok := c.Path(imageURL)
+			if !ok {
+				return tag
+			}
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+			local = (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+		}
+
+		tag = srcsetPattern.ReplaceAllString(tag, "")
+		return srcPattern.ReplaceAllLiteralString(tag, m[1]+`"`+html.EscapeString(local)+`"`)
+	})
+}
+
+// RewriteEntry returns the content of an entry with its cached images
+// pointed at the cache.
+func (c *ImageCache) RewriteEntry(entry *Entry, mode ImageRewrite) string {
+	return c.RewriteHTML(entry.Content, entry.URL, mode)
+}
+
+// Size returns the total size of the cached images.
+func (c *ImageCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var size int64
+	for _, blob := range c.index.Blobs {
+		size += blob.Size
+	}
+	return size
+}
+
+// Evict removes the least recently used images until the cache is within
+// its size limit, saves the index and returns the number of files removed.
+func (c *ImageCache) Evict() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	evicted := 0
+	if c.maxBytes > 0 {
+		var size int64
+		hashes := make([]string, 0, len(c.index.Blobs))
+		for hash, blob := range c.index.Blobs {
+			size += blob.Size
+			hashes = append(hashes, hash)
+		}
+
+		sort.Slice(hashes, func(i, j int) bool {
+			a, b := c.index.Blobs[hashes[i]], c.index.Blobs[hashes[j]]
+			if !a.LastUsed.Equal(b.LastUsed) {
+				return a.LastUsed.Before(b.LastUsed)
+			}
+			return hashes[i] < hashes[j]
+		})
+
+		for _, hash := range hashes {
+			if size <= c.maxBytes {
+				break
+			}
+
+			blob := c.index.Blobs[hash]
+			if err := os.Remove(filepath.Join(c.dir, blob.File)); err != nil && !os.IsNotExist(err) {
+				return evicted, err
+			}
+
+			size -= blob.Size
+			delete(c.index.Blobs, hash)
+			for u, h := range c.index.URLs {
+				if h == hash {
+					delete(c.index.URLs, u)
+				}
+			}
+			evicted++
+		}
+	}
+
+	return evicted, c.save()
+}
+
+// lookup returns the blob of a cached image and marks it as used.
+func (c *ImageCache) lookup(imageURL string) (*imageBlob, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	blob, ok := c.index.Blobs[c.index.URLs[imageURL]]
+	if !ok {
+		return nil, false
+	}
+	blob.LastUsed = time.Now().UTC()
+	copied := *blob
+	return &copied, true
+}
+
+// download fetches an image and stores it under the hash of its content.
+func (c *ImageCache) download(ctx context.Context, imageURL string, maxBytes int64) error {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxImageBytes
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", c.client.userAgent)
+
+	resp, err := c.client.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("image returned status code %d", resp.StatusCode)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("unexpected content type %q", contentType)
+	}
+
+	if resp.ContentLength > maxBytes {
+		return ErrImageTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > maxBytes {
+		return ErrImageTooLarge
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	file := hash + imageExtension(contentType)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.index.Blobs[hash]; !ok {
+		path := filepath.Join(c.dir, file)
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, data, 0o644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+
+		c.index.Blobs[hash] = &imageBlob{
+			File:        file,
+			ContentType: contentType,
+			Size:        int64(len(data)),
+		}
+	}
+
+	c.index.Blobs[hash].LastUsed = time.Now().UTC()
+	c.index.URLs[imageURL] = hash
+
+	return nil
+}
+
+// save writes the index. The caller must hold the lock.
+func (c *ImageCache) save() error {
+	data, err := json.Marshal(&c.index)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(c.dir, imageIndexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// imageExtension returns the file extension for an image content type.
+func imageExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/svg+xml":
+		return ".svg"
+	case "image/avif":
+		return ".avif"
+	}
+	return ""
+}
+
+// attributeValue returns an HTML attribute value without quotes and with
+// character references decoded.
+func attributeValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		value = value[1 : len(value)-1]
+	}
+	return html.UnescapeString(strings.TrimSpace(value))
+}
+
+// resolveImageURL resolves an image URL against the URL of its entry.
+func resolveImageURL(baseURL, imageURL string) string {
+	if imageURL == "" || strings.HasPrefix(imageURL, "data:") {
+		return ""
+	}
+
+	ref, err := url.Parse(imageURL)
+	if err != nil {
+		return ""
+	}
+
+	if base, err := url.Parse(baseURL); err == nil && baseURL != "" {
+		ref = base.ResolveReference(ref)
+	}
+
+	if ref.Scheme != "http" && ref.Scheme != "https" {
+		return ""
+	}
+
+	return ref.String()
+}
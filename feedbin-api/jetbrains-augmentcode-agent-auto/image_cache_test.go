@@ -0,0 +1,142 @@
+package feedbin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEntryImageURLs(t *testing.T) {
+	entry := &Entry{
+		URL:     "https://example.com/posts/1",
+		Content: `<p><img src="/a.png"><img alt="b" src='https://cdn.example.com/b.jpg?w=1&amp;h=2'><img src="data:image/gif;base64,R0lG"></p>`,
+		Images: &Images{
+			OriginalURL: "https://example.com/original.jpg",
+			Size1:       &ImageSize{CDNURL: "https://images.example.com/size_1.jpg"},
+		},
+	}
+
+	want := []string{
+		"https://images.example.com/size_1.jpg",
+		"https://example.com/original.jpg",
+		"https://example.com/a.png",
+		"https://cdn.example.com/b.jpg?w=1&h=2",
+	}
+
+	if got := EntryImageURLs(entry); !reflect.DeepEqual(got, want) {
+		t.Errorf("EntryImageURLs = %v, want %v", got, want)
+	}
+}
+
+func TestImageCache_PrefetchAndRewrite(t *testing.T) {
+	requests := 0
+
+	// Create a test server serving two identical images and a page
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/a.png", "/b.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png data"))
+		case "/big.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cache, err := NewImageCache(NewClient("username", "password"), dir, 0)
+	if err != nil {
+		t.Fatalf("NewImageCache returned error: %v", err)
+	}
+
+	entry := &Entry{
+		URL:     server.URL + "/post",
+		Content: `<img src="/a.png" srcset="/a@2x.png 2x"><img src="/b.png"><img src="/page"><img src="/big.png">`,
+	}
+
+	result, err := cache.Prefetch(context.Background(), []*Entry{entry}, &ImagePrefetchOptions{MaxImageBytes: 50})
+	if err != nil {
+		t.Fatalf("Prefetch returned error: %v", err)
+	}
+	if result.Downloaded != 2 || len(result.Errors) != 2 {
+		t.Errorf("Prefetch = %+v, want 2 downloaded and 2 errors", result)
+	}
+	if result.Errors[server.URL+"/big.png"] != ErrImageTooLarge {
+		t.Errorf("Error for big image = %v, want %v", result.Errors[server.URL+"/big.png"], ErrImageTooLarge)
+	}
+
+	// Identical images are stored once
+	if got := cache.Size(); got != int64(len("png data")) {
+		t.Errorf("Size = %d, want %d", got, len("png data"))
+	}
+
+	rewritten := cache.RewriteEntry(entry, ImageRewriteDataURI)
+	if !strings.Contains(rewritten, `<img src="data:image/png;base64,cG5nIGRhdGE=">`) || strings.Contains(rewritten, "srcset") {
+		t.Errorf("RewriteEntry = %q", rewritten)
+	}
+
+	path, ok := cache.Path(server.URL + "/a.png")
+	if !ok || !strings.HasSuffix(path, ".png") {
+		t.Errorf("Path = %q, %v", path, ok)
+	}
+	if rewritten := cache.RewriteEntry(entry, ImageRewriteFile); !strings.Contains(rewritten, `src="file://`) {
+		t.Errorf("RewriteEntry = %q", rewritten)
+	}
+
+	// A second prefetch uses the cache, including after reopening it
+	reopened, err := NewImageCache(NewClient("username", "password"), dir, 0)
+	if err != nil {
+		t.Fatalf("NewImageCache returned error: %v", err)
+	}
+	before := requests
+	result, err = reopened.Prefetch(context.Background(), []*Entry{{Content: `<img src="` + server.URL + `/a.png">`}}, nil)
+	if err != nil {
+		t.Fatalf("Prefetch returned error: %v", err)
+	}
+	if result.Cached != 1 || requests != before {
+		t.Errorf("Prefetch = %+v after %d requests, want 1 cached and no requests", result, requests-before)
+	}
+}
+
+func TestImageCache_Evict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte(strings.Repeat(r.URL.Path, 5)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cache, err := NewImageCache(NewClient("username", "password"), dir, 40)
+	if err != nil {
+		t.Fatalf("NewImageCache returned error: %v", err)
+	}
+
+	for _, name := range []string{"/one", "/two", "/six"} {
+		entry := &Entry{Content: `<img src="` + server.URL + name + `">`}
+		if _, err := cache.Prefetch(context.Background(), []*Entry{entry}, nil); err != nil {
+			t.Fatalf("Prefetch returned error: %v", err)
+		}
+	}
+
+	// Each image is 20 bytes, so only the two most recent fit
+	if _, ok := cache.Path(server.URL + "/one"); ok {
+		t.Error("Expected the least recently used image to be evicted")
+	}
+	if size := cache.Size(); size != 40 {
+		t.Errorf("Size = %d, want 40", size)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 3 {
+		t.Errorf("Cache directory has %d files, want 2 images and the index", len(files))
+	}
+}
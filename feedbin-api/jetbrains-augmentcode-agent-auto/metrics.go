@@ -0,0 +1,338 @@
+package feedbin
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMetricsBuckets are the default upper bounds, in seconds, of the
+// request latency histogram.
+var DefaultMetricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RequestMetrics describes a single HTTP request made by the client.
+type RequestMetrics struct {
+	// Method is the HTTP method of the request.
+	Method string
+
+	// Endpoint is the templated request path, with IDs replaced by
+	// placeholders, for example "/v2/feeds/:id/entries.json".
+	Endpoint string
+
+	// StatusCode is the response status code, or 0 if no response was
+	// received.
+	StatusCode int
+
+	// Duration is the time from sending the request to reading the response.
+	Duration time.Duration
+
+	// RequestBytes and ResponseBytes are the sizes of the request and
+	// response bodies.
+	RequestBytes  int64
+	ResponseBytes int64
+
+	// RecordCount is the value of the X-Feedbin-Record-Count header, or -1
+	// if the response did not have one.
+	RecordCount int
+
+	// Attempt is 1 for the first attempt of a request and higher for retries.
+	Attempt int
+
+	// Err is the error returned for the request, if any.
+	Err error
+}
+
+// MetricsCollector receives metrics for every request made by the client.
+// Implementations must be safe for concurrent use.
+type MetricsCollector interface {
+	ObserveRequest(m *RequestMetrics)
+}
+
+// SetMetrics sets the collector that receives request metrics. A nil
+// collector disables metrics.
+func (c *Client) SetMetrics(collector MetricsCollector) {
+	c.metrics = collector
+}
+
+// observe reports a request to the metrics collector, if there is one.
+func (c *Client) observe(req *http.Request, resp *http.Response, start time.Time, responseBytes int64, attempt int, err error) {
+	if c.metrics == nil {
+		return
+	}
+
+	m := &RequestMetrics{
+		Method:        req.Method,
+		Endpoint:      EndpointTemplate(req.URL.Path),
+		Duration:      time.Since(start),
+		ResponseBytes: responseBytes,
+		RecordCount:   -1,
+		Attempt:       attempt,
+		Err:           err,
+	}
+
+	if req.ContentLength > 0 {
+		m.RequestBytes = req.ContentLength
+	}
+
+	if resp != nil {
+		m.StatusCode = resp.StatusCode
+		if count := resp.Header.Get("X-Feedbin-Record-Count"); count != "" {
+			if n, err := strconv.Atoi(count); err == nil {
+				m.RecordCount = n
+			}
+		}
+	}
+
+	c.metrics.ObserveRequest(m)
+}
+
+// EndpointTemplate returns path with IDs replaced by placeholders, so that
+// it can be used as a metrics label: numeric segments become ":id" and the
+// username and signature of extract service paths become ":username" and
+// ":signature".
+func EndpointTemplate(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		if i > 0 && segments[i-1] == "parser" && i+1 < len(segments) {
+			segments[i] = ":username"
+			segments[i+1] = ":signature"
+			break
+		}
+
+		name, ext := segment, ""
+		if dot := strings.IndexByte(segment, '.'); dot >= 0 {
+			name, ext = segment[:dot], segment[dot:]
+		}
+
+		if name != "" && isDigits(name) {
+			segments[i] = ":id" + ext
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// isDigits reports whether s consists only of ASCII digits.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// countingReader counts the bytes read from a response body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// PrometheusCollector is a MetricsCollector that keeps request metrics in
+// memory and serves them in the Prometheus text exposition format. It
+// implements http.Handler, so it can be mounted on a metrics endpoint.
+type PrometheusCollector struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	requests  map[metricsKey]float64
+	latencies map[metricsKey]*latencyHistogram
+	sent      map[metricsKey]float64
+	received  map[metricsKey]float64
+	retries   map[metricsKey]float64
+	records   map[metricsKey]float64
+}
+
+// metricsKey identifies a metric series by its labels.
+type metricsKey struct {
+	method   string
+	endpoint string
+	code     string
+}
+
+// latencyHistogram holds the cumulative bucket counts of a histogram.
+type latencyHistogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheusCollector returns a collector using the given latency
+// histogram buckets in seconds, or DefaultMetricsBuckets if none are given.
+func NewPrometheusCollector(buckets ...float64) *PrometheusCollector {
+	if len(buckets) == 0 {
+		buckets = DefaultMetricsBuckets
+	}
+
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &PrometheusCollector{
+		buckets:   sorted,
+		requests:  make(map[metricsKey]float64),
+		latencies: make(map[metricsKey]*latencyHistogram),
+		sent:      make(map[metricsKey]float64),
+		received:  make(map[metricsKey]float64),
+		retries:   make(map[metricsKey]float64),
+		records:   make(map[metricsKey]float64),
+	}
+}
+
+// ObserveRequest records a request.
+func (p *PrometheusCollector) ObserveRequest(m *RequestMetrics) {
+	key := metricsKey{method: m.Method, endpoint: m.Endpoint}
+
+	code := "error"
+	if m.StatusCode > 0 {
+		code = strconv.Itoa(m.StatusCode)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests[metricsKey{method: m.Method, endpoint: m.Endpoint, code: code}]++
+	p.sent[key] += float64(m.RequestBytes)
+	p.received[key] += float64(m.ResponseBytes)
+
+	if m.Attempt > 1 {
+		p.retries[key]++
+	}
+
+	if m.RecordCount >= 0 {
+		p.records[key] = float64(m.RecordCount)
+	}
+
+	h := p.latencies[key]
+	if h == nil {
+		h = &latencyHistogram{counts: make([]uint64, len(p.buckets))}
+		p.latencies[key] = h
+	}
+
+	seconds := m.Duration.Seconds()
+	for i, bound := range p.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (p *PrometheusCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (p *PrometheusCollector) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+
+	writeSeries(&b, "feedbin_client_requests_total", "Requests made to the Feedbin API by method, endpoint and status code.", "counter", p.requests)
+	p.writeHistogram(&b)
+	writeSeries(&b, "feedbin_client_request_bytes_total", "Bytes sent in request bodies.", "counter", p.sent)
+	writeSeries(&b, "feedbin_client_response_bytes_total", "Bytes received in response bodies.", "counter", p.received)
+	writeSeries(&b, "feedbin_client_retries_total", "Requests that were retries of an earlier attempt.", "counter", p.retries)
+	writeSeries(&b, "feedbin_client_record_count", "Last X-Feedbin-Record-Count returned by the endpoint.", "gauge", p.records)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeHistogram writes the latency histogram.
+func (p *PrometheusCollector) writeHistogram(b *strings.Builder) {
+	const name = "feedbin_client_request_duration_seconds"
+	if len(p.latencies) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "# HELP %s Request latency in seconds.\n", name)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+
+	keys := make([]metricsKey, 0, len(p.latencies))
+	for key := range p.latencies {
+		keys = append(keys, key)
+	}
+
+	for _, key := range sortMetricsKeys(keys) {
+		h := p.latencies[key]
+		labels := key.labels()
+		for i, bound := range p.buckets {
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatMetricValue(bound), h.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, formatMetricValue(h.sum))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+// writeSeries writes a counter or gauge with one value per series.
+func writeSeries(b *strings.Builder, name, help, kind string, values map[metricsKey]float64) {
+	if len(values) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, kind)
+
+	keys := make([]metricsKey, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	for _, key := range sortMetricsKeys(keys) {
+		fmt.Fprintf(b, "%s{%s} %s\n", name, key.labels(), formatMetricValue(values[key]))
+	}
+}
+
+// labels formats the key as Prometheus labels.
+func (k metricsKey) labels() string {
+	labels := fmt.Sprintf(`method="%s",endpoint="%s"`, escapeLabel(k.method), escapeLabel(k.endpoint))
+	if k.code != "" {
+		labels += fmt.Sprintf(`,code="%s"`, escapeLabel(k.code))
+	}
+	return labels
+}
+
+// sortMetricsKeys sorts series keys into a stable order.
+func sortMetricsKeys(keys []metricsKey) []metricsKey {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].code < keys[j].code
+	})
+	return keys
+}
+
+// escapeLabel escapes a label value for the text exposition format.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatMetricValue formats a sample value for the text exposition format.
+func formatMetricValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,74 @@
+package feedbin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v2/entries.json", "/v2/entries.json"},
+		{"/v2/feeds/42/entries.json", "/v2/feeds/:id/entries.json"},
+		{"/v2/subscriptions/7.json", "/v2/subscriptions/:id.json"},
+		{"/v2/saved_searches/3.json", "/v2/saved_searches/:id.json"},
+		{"/parser/username/0a1b2c", "/parser/:username/:signature"},
+	}
+
+	for _, tt := range tests {
+		if got := EndpointTemplate(tt.path); got != tt.want {
+			t.Errorf("EndpointTemplate(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPrometheusCollector(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/feeds/2/entries.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Feedbin-Record-Count", "3")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	collector := NewPrometheusCollector()
+
+	client := NewClient("username", "password")
+	client.SetBaseURL(server.URL)
+	client.SetMetrics(collector)
+
+	client.Entries.ListByFeed(1, nil)
+	client.Entries.ListByFeed(1, nil)
+	client.Entries.ListByFeed(2, nil)
+
+	// Serve the metrics through the handler
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE feedbin_client_requests_total counter",
+		`feedbin_client_requests_total{method="GET",endpoint="/v2/feeds/:id/entries.json",code="200"} 2`,
+		`feedbin_client_requests_total{method="GET",endpoint="/v2/feeds/:id/entries.json",code="404"} 1`,
+		"# TYPE feedbin_client_request_duration_seconds histogram",
+		`feedbin_client_request_duration_seconds_bucket{method="GET",endpoint="/v2/feeds/:id/entries.json",le="+Inf"} 3`,
+		`feedbin_client_request_duration_seconds_count{method="GET",endpoint="/v2/feeds/:id/entries.json"} 3`,
+		`feedbin_client_response_bytes_total{method="GET",endpoint="/v2/feeds/:id/entries.json"} 4`,
+		`feedbin_client_record_count{method="GET",endpoint="/v2/feeds/:id/entries.json"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Metrics output missing %q, got:\n%s", want, body)
+		}
+	}
+
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Metrics Content-Type = %v", got)
+	}
+}
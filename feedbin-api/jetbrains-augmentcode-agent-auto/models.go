@@ -47,7 +47,7 @@ type Entry struct {
 	CreatedAt           time.Time  `json:"created_at"`
 	Original            *Entry     `json:"original,omitempty"`
 	ContentDiff         string     `json:"content_diff,omitempty"`
-	Images              []string   `json:"images,omitempty"`
+	Images              *Images    `json:"images,omitempty"`
 	Enclosure           *Enclosure `json:"enclosure,omitempty"`
 	TwitterID           int64      `json:"twitter_id,omitempty"`
 	TwitterThreadIDs    []int64    `json:"twitter_thread_ids,omitempty"`
@@ -55,6 +55,19 @@ type Entry struct {
 	JSONFeed            *JSONFeed  `json:"json_feed,omitempty"`
 }
 
+// Images represents the image of an entry in extended mode.
+type Images struct {
+	OriginalURL string     `json:"original_url"`
+	Size1       *ImageSize `json:"size_1,omitempty"`
+}
+
+// ImageSize represents a resized copy of an entry image.
+type ImageSize struct {
+	CDNURL string `json:"cdn_url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
 // Enclosure represents podcast/RSS enclosure data.
 type Enclosure struct {
 	URL      string `json:"url"`
@@ -0,0 +1,362 @@
+package feedbin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPrefetchConcurrency is the default number of concurrent extractions.
+	DefaultPrefetchConcurrency = 4
+
+	// DefaultPrefetchPerHost is the default number of concurrent extractions
+	// of pages on the same host.
+	DefaultPrefetchPerHost = 1
+
+	// DefaultPrefetchHostDelay is the default pause between extractions of
+	// pages on the same host.
+	DefaultPrefetchHostDelay = time.Second
+)
+
+// ContentStore stores extracted articles by entry ID.
+type ContentStore interface {
+	// Get returns the stored article for an entry, or nil if there is none.
+	Get(entryID int64) (*ExtractedArticle, error)
+
+	// Put stores the article for an entry.
+	Put(entryID int64, article *ExtractedArticle) error
+}
+
+// MemoryContentStore is a ContentStore that keeps articles in memory.
+type MemoryContentStore struct {
+	mu       sync.RWMutex
+	articles map[int64]*ExtractedArticle
+}
+
+// NewMemoryContentStore returns an empty in-memory content store.
+func NewMemoryContentStore() *MemoryContentStore {
+	return &MemoryContentStore{articles: make(map[int64]*ExtractedArticle)}
+}
+
+// Get returns the stored article for an entry.
+func (m *MemoryContentStore) Get(entryID int64) (*ExtractedArticle, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.articles[entryID], nil
+}
+
+// Put stores the article for an entry.
+func (m *MemoryContentStore) Put(entryID int64, article *ExtractedArticle) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.articles[entryID] = article
+	return nil
+}
+
+// FileContentStore is a ContentStore that keeps one JSON file per entry in a
+// directory, so articles can be read offline.
+type FileContentStore struct {
+	dir string
+}
+
+// NewFileContentStore returns a content store in dir, creating it if needed.
+func NewFileContentStore(dir string) (*FileContentStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileContentStore{dir: dir}, nil
+}
+
+// Get returns the stored article for an entry.
+func (f *FileContentStore) Get(entryID int64) (*ExtractedArticle, error) {
+	data, err := os.ReadFile(f.path(entryID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var article ExtractedArticle
+	if err := json.Unmarshal(data, &article); err != nil {
+		return nil, fmt.Errorf("error reading stored article for entry %d: %w", entryID, err)
+	}
+
+	return &article, nil
+}
+
+// Put stores the article for an entry.
+func (f *FileContentStore) Put(entryID int64, article *ExtractedArticle) error {
+	data, err := json.Marshal(article)
+	if err != nil {
+		return err
+	}
+
+	path := f.path(entryID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// path returns the file of an entry's article.
+func (f *FileContentStore) path(entryID int64) string {
+	return filepath.Join(f.dir, strconv.FormatInt(entryID, 10)+".json")
+}
+
+// PrefetchOptions specifies the optional parameters to the prefetch methods.
+type PrefetchOptions struct {
+	// Concurrency is the maximum number of extractions in flight.
+	Concurrency int
+
+	// PerHost is the maximum number of extractions in flight for pages on
+	// the same host.
+	PerHost int
+
+	// HostDelay is the minimum time between starting extractions of pages
+	// on the same host. Zero uses DefaultPrefetchHostDelay and a negative
+	// value disables the delay.
+	HostDelay time.Duration
+
+	// Refresh extracts entries that are already in the store again.
+	Refresh bool
+}
+
+// PrefetchResult summarizes a prefetch run.
+type PrefetchResult struct {
+	Extracted int
+	Skipped   int
+	Errors    map[int64]error
+}
+
+// Prefetch extracts the full content of entries into store with bounded
+// concurrency. Entries already in the store are skipped unless opts.Refresh
+// is set. Failed extractions are reported in the result; the returned error
+// is only set when ctx is done or the store fails.
+func (s *ExtractService) Prefetch(ctx context.Context, entries []*Entry, store ContentStore, opts *PrefetchOptions) (*PrefetchResult, error) {
+	if opts == nil {
+		opts = &PrefetchOptions{}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultPrefetchConcurrency
+	}
+
+	perHost := opts.PerHost
+	if perHost <= 0 {
+		perHost = DefaultPrefetchPerHost
+	}
+
+	hostDelay := opts.HostDelay
+	if hostDelay < 0 {
+		hostDelay = 0
+	} else if hostDelay == 0 {
+		hostDelay = DefaultPrefetchHostDelay
+	}
+
+	result := &PrefetchResult{Errors: make(map[int64]error)}
+	var mu sync.Mutex
+	var storeErr error
+
+	hosts := newHostLimiter(perHost, hostDelay)
+	jobs := make(chan *Entry)
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range jobs {
+				article, err := s.prefetchEntry(ctx, hosts, entry)
+
+				mu.Lock()
+				if err != nil {
+					result.Errors[entry.ID] = err
+				} else if err := store.Put(entry.ID, article); err != nil {
+					if storeErr == nil {
+						storeErr = err
+					}
+				} else {
+					result.Extracted++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var err error
+	for _, entry := range entries {
+		if !opts.Refresh {
+			stored, getErr := store.Get(entry.ID)
+			if getErr != nil {
+				err = getErr
+				break
+			}
+			if stored != nil {
+				result.Skipped++
+				continue
+			}
+		}
+
+		if entry.URL == "" {
+			mu.Lock()
+			result.Errors[entry.ID] = fmt.Errorf("entry %d has no URL", entry.ID)
+			mu.Unlock()
+			continue
+		}
+
+		mu.Lock()
+		failed := storeErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+
+		select {
+		case jobs <- entry:
+			continue
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		break
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if err == nil {
+		err = storeErr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	return result, err
+}
+
+// PrefetchUnread extracts the full content of every unread entry into store.
+func (s *ExtractService) PrefetchUnread(ctx context.Context, store ContentStore, opts *PrefetchOptions) (*PrefetchResult, error) {
+	ids, _, err := s.client.UnreadEntries.List()
+	if err != nil {
+		return nil, err
+	}
+	return s.prefetchIDs(ctx, ids, store, opts)
+}
+
+// PrefetchStarred extracts the full content of every starred entry into store.
+func (s *ExtractService) PrefetchStarred(ctx context.Context, store ContentStore, opts *PrefetchOptions) (*PrefetchResult, error) {
+	ids, _, err := s.client.StarredEntries.List()
+	if err != nil {
+		return nil, err
+	}
+	return s.prefetchIDs(ctx, ids, store, opts)
+}
+
+// prefetchIDs loads entries by ID, 100 at a time, and prefetches them.
+func (s *ExtractService) prefetchIDs(ctx context.Context, ids []int64, store ContentStore, opts *PrefetchOptions) (*PrefetchResult, error) {
+	var entries []*Entry
+	for start := 0; start < len(ids); start += 100 {
+		end := start + 100
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batch, _, err := s.client.Entries.List(&EntryListOptions{IDs: ids[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, batch...)
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.Prefetch(ctx, entries, store, opts)
+}
+
+// prefetchEntry extracts one entry while holding its host's limit.
+func (s *ExtractService) prefetchEntry(ctx context.Context, hosts *hostLimiter, entry *Entry) (*ExtractedArticle, error) {
+	host := entry.URL
+	if u, err := url.Parse(entry.URL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	release, err := hosts.acquire(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return s.ExtractContext(ctx, entry.URL)
+}
+
+// hostLimiter limits concurrent requests per host and spaces out their starts.
+type hostLimiter struct {
+	perHost int
+	delay   time.Duration
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+	next  map[string]time.Time
+}
+
+// newHostLimiter returns a limiter allowing perHost requests per host.
+func newHostLimiter(perHost int, delay time.Duration) *hostLimiter {
+	return &hostLimiter{
+		perHost: perHost,
+		delay:   delay,
+		slots:   make(map[string]chan struct{}),
+		next:    make(map[string]time.Time),
+	}
+}
+
+// acquire waits for a slot on host and for the host delay to pass. The
+// returned function releases the slot.
+func (h *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	h.mu.Lock()
+	slots, ok := h.slots[host]
+	if !ok {
+		slots = make(chan struct{}, h.perHost)
+		h.slots[host] = slots
+	}
+	h.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	h.mu.Lock()
+	now := time.Now()
+	start := h.next[host]
+	if start.Before(now) {
+		start = now
+	}
+	h.next[host] = start.Add(h.delay)
+	h.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			<-slots
+			return nil, ctx.Err()
+		}
+	}
+
+	return func() { <-slots }, nil
+}
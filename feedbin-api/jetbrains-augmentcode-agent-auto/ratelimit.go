@@ -0,0 +1,279 @@
+package feedbin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRateLimitRetries is the number of times a request answered with
+	// 429 Too Many Requests is retried.
+	DefaultRateLimitRetries = 3
+
+	// DefaultRateLimitBackoff is how long requests are held back after a 429
+	// response without a Retry-After header.
+	DefaultRateLimitBackoff = 5 * time.Second
+
+	// minRateFactor is the lowest fraction of the configured rates that
+	// repeated 429 responses can reduce the limiter to.
+	minRateFactor = 1.0 / 16
+
+	// rateRecovery is how much of the configured rates each successful
+	// response restores after a slowdown.
+	rateRecovery = 0.05
+
+	// priorityPoll is how often background requests check whether
+	// interactive requests are still waiting.
+	priorityPoll = 10 * time.Millisecond
+)
+
+// Priority is the priority of requests waiting for the rate limiter.
+type Priority int
+
+const (
+	// PriorityInteractive requests go ahead of background requests. It is
+	// the default.
+	PriorityInteractive Priority = iota
+
+	// PriorityBackground requests wait while interactive requests are
+	// waiting.
+	PriorityBackground
+)
+
+// RateLimit is the budget of a token bucket: Rate requests per second on
+// average, with bursts of up to Burst requests.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimiter is a client-side token-bucket rate limiter with separate
+// budgets for reads (GET requests) and writes (all other requests). When the
+// server answers 429 Too Many Requests, requests are held back for the
+// Retry-After time and the rates are halved, recovering gradually with
+// successful responses. It is safe for concurrent use.
+type RateLimiter struct {
+	// MaxRetries is the number of times a request answered with 429 is
+	// retried.
+	MaxRetries int
+
+	mu           sync.Mutex
+	read         tokenBucket
+	write        tokenBucket
+	factor       float64
+	blockedUntil time.Time
+	interactive  int
+}
+
+// tokenBucket holds the state of one budget.
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a rate limiter with the given read and write
+// budgets. A zero Rate leaves that kind of request unlimited, and a Burst
+// below 1 is treated as 1.
+func NewRateLimiter(read, write RateLimit) *RateLimiter {
+	if read.Burst < 1 {
+		read.Burst = 1
+	}
+	if write.Burst < 1 {
+		write.Burst = 1
+	}
+
+	now := time.Now()
+	return &RateLimiter{
+		MaxRetries: DefaultRateLimitRetries,
+		read:       tokenBucket{limit: read, tokens: float64(read.Burst), last: now},
+		write:      tokenBucket{limit: write, tokens: float64(write.Burst), last: now},
+		factor:     1,
+	}
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context, write bool, priority Priority) error {
+	if priority == PriorityInteractive {
+		l.mu.Lock()
+		l.interactive++
+		l.mu.Unlock()
+
+		defer func() {
+			l.mu.Lock()
+			l.interactive--
+			l.mu.Unlock()
+		}()
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		wait := l.waitTime(write, now)
+
+		if wait == 0 && priority == PriorityBackground && l.interactive > 0 {
+			wait = priorityPoll
+		}
+
+		if wait == 0 {
+			bucket := l.bucket(write)
+			if bucket.limit.Rate > 0 {
+				bucket.tokens--
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// WaitTime returns how long a read or write request would currently wait,
+// for schedulers that want to back off instead of blocking.
+func (l *RateLimiter) WaitTime(write bool) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waitTime(write, time.Now())
+}
+
+// Observe adjusts the limiter to a response. A 429 response, or any
+// response with a Retry-After header, holds requests back; a 429 also
+// halves the rates. Other successful responses restore them gradually.
+func (l *RateLimiter) Observe(resp *http.Response) {
+	now := time.Now()
+	retryAfter, hasRetryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		l.refill(now)
+		l.factor /= 2
+		if l.factor < minRateFactor {
+			l.factor = minRateFactor
+		}
+		if !hasRetryAfter {
+			retryAfter = DefaultRateLimitBackoff
+		}
+	} else if resp.StatusCode < 400 && l.factor < 1 {
+		l.refill(now)
+		l.factor += rateRecovery
+		if l.factor > 1 {
+			l.factor = 1
+		}
+	}
+
+	if until := now.Add(retryAfter); retryAfter > 0 && until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
+
+// bucket returns the read or write bucket.
+func (l *RateLimiter) bucket(write bool) *tokenBucket {
+	if write {
+		return &l.write
+	}
+	return &l.read
+}
+
+// refill adds the tokens earned since the last refill to both buckets at
+// the current rates. The caller must hold the lock.
+func (l *RateLimiter) refill(now time.Time) {
+	for _, bucket := range []*tokenBucket{&l.read, &l.write} {
+		if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+			bucket.tokens += elapsed * bucket.limit.Rate * l.factor
+			if burst := float64(bucket.limit.Burst); bucket.tokens > burst {
+				bucket.tokens = burst
+			}
+		}
+		bucket.last = now
+	}
+}
+
+// waitTime returns how long until a token is available. The caller must
+// hold the lock.
+func (l *RateLimiter) waitTime(write bool, now time.Time) time.Duration {
+	l.refill(now)
+
+	var wait time.Duration
+	if now.Before(l.blockedUntil) {
+		wait = l.blockedUntil.Sub(now)
+	}
+
+	bucket := l.bucket(write)
+	if bucket.limit.Rate > 0 && bucket.tokens < 1 {
+		tokenWait := time.Duration((1 - bucket.tokens) / (bucket.limit.Rate * l.factor) * float64(time.Second))
+		if tokenWait <= 0 {
+			tokenWait = time.Nanosecond
+		}
+		if tokenWait > wait {
+			wait = tokenWait
+		}
+	}
+
+	return wait
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if wait := t.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// SetRateLimiter sets the rate limiter for API requests. A nil limiter
+// turns rate limiting off.
+func (c *Client) SetRateLimiter(limiter *RateLimiter) {
+	c.limiter = limiter
+}
+
+// RateLimiter returns the client's rate limiter, or nil.
+func (c *Client) RateLimiter() *RateLimiter {
+	return c.limiter
+}
+
+// WithPriority returns a copy of the client whose requests wait for the rate
+// limiter with the given priority:
+//
+//	client.WithPriority(feedbin.PriorityBackground).Entries.List(opts)
+func (c *Client) WithPriority(priority Priority) *Client {
+	pc := c.clone()
+	pc.priority = priority
+	return pc
+}
+
+// WithContext returns a copy of the client whose requests use ctx, so that
+// waiting for the rate limiter and the requests themselves are cancelled
+// when ctx is done.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	cc := c.clone()
+	cc.ctx = ctx
+	return cc
+}
@@ -0,0 +1,113 @@
+package feedbin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_WaitTime(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{Rate: 1, Burst: 1}, RateLimit{})
+
+	if wait := limiter.WaitTime(false); wait != 0 {
+		t.Errorf("WaitTime with a full bucket = %v, want 0", wait)
+	}
+
+	if err := limiter.Wait(context.Background(), false, PriorityInteractive); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	if wait := limiter.WaitTime(false); wait <= 0 || wait > time.Second {
+		t.Errorf("WaitTime with an empty bucket = %v, want up to 1s", wait)
+	}
+
+	// Writes have their own, unlimited, budget
+	if wait := limiter.WaitTime(true); wait != 0 {
+		t.Errorf("WaitTime for writes = %v, want 0", wait)
+	}
+
+	// Waiting respects the context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, false, PriorityInteractive); err != context.DeadlineExceeded {
+		t.Errorf("Wait error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRateLimiter_Priority(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{Rate: 20, Burst: 1}, RateLimit{})
+	limiter.Wait(context.Background(), false, PriorityInteractive)
+
+	var mu sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+
+	wait := func(priority Priority) {
+		defer wg.Done()
+		limiter.Wait(context.Background(), false, priority)
+		mu.Lock()
+		order = append(order, priority)
+		mu.Unlock()
+	}
+
+	wg.Add(2)
+	go wait(PriorityBackground)
+	time.Sleep(5 * time.Millisecond)
+	go wait(PriorityInteractive)
+	wg.Wait()
+
+	if len(order) != 2 || order[0] != PriorityInteractive {
+		t.Errorf("Requests were let through in order %v, want interactive first", order)
+	}
+}
+
+func TestClient_RateLimitRetry(t *testing.T) {
+	requests := 0
+
+	// Create a test server that throttles the first request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`[1, 2]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("username", "password")
+	client.SetBaseURL(server.URL)
+	limiter := NewRateLimiter(RateLimit{Rate: 1000, Burst: 10}, RateLimit{Rate: 1000, Burst: 10})
+	client.SetRateLimiter(limiter)
+
+	ids, _, err := client.UnreadEntries.List()
+	if err != nil {
+		t.Fatalf("UnreadEntries.List returned error: %v", err)
+	}
+	if len(ids) != 2 || requests != 2 {
+		t.Errorf("UnreadEntries.List = %v after %d requests, want 2 IDs after 2 requests", ids, requests)
+	}
+
+	if limiter.factor != 0.5+rateRecovery {
+		t.Errorf("Rate factor = %v, want %v", limiter.factor, 0.5+rateRecovery)
+	}
+}
+
+func TestClient_WithContext(t *testing.T) {
+	client := NewClient("username", "password")
+	client.SetRateLimiter(NewRateLimiter(RateLimit{Rate: 0.001, Burst: 1}, RateLimit{}))
+
+	// Use up the only token
+	client.RateLimiter().Wait(context.Background(), false, PriorityInteractive)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := client.WithContext(ctx).UnreadEntries.List(); err != context.Canceled {
+		t.Errorf("UnreadEntries.List error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -36,53 +36,82 @@ type GetOptions struct {
 	Page           int
 }
 
-// Get returns a single saved search.
+// Get returns the results of a saved search: a []int64 of entry IDs, or a
+// []*Entry when opts.IncludeEntries is set. GetEntryIDs and GetEntries return
+// the results with their concrete types.
 func (s *SavedSearchesService) Get(id int64, opts *GetOptions) (interface{}, *http.Response, error) {
-	u := fmt.Sprintf("/v2/saved_searches/%d.json", id)
-
+	page := 0
 	if opts != nil {
-		params := url.Values{}
+		page = opts.Page
+	}
 
-		if opts.IncludeEntries {
-			params.Add("include_entries", "true")
+	if opts != nil && opts.IncludeEntries {
+		entries, resp, err := s.GetEntries(id, page)
+		if err != nil {
+			return nil, resp, err
 		}
+		return entries, resp, nil
+	}
 
-		if opts.Page > 0 {
-			params.Add("page", strconv.Itoa(opts.Page))
-		}
+	entryIDs, resp, err := s.GetEntryIDs(id, page)
+	if err != nil {
+		return nil, resp, err
+	}
+	return entryIDs, resp, nil
+}
 
-		if len(params) > 0 {
-			u += "?" + params.Encode()
-		}
+// GetEntryIDs returns the IDs of the entries matching a saved search, in
+// order. A page of zero returns the first page.
+func (s *SavedSearchesService) GetEntryIDs(id int64, page int) ([]int64, *http.Response, error) {
+	req, err := s.client.NewRequest(http.MethodGet, savedSearchURL(id, false, page), nil)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	req, err := s.client.NewRequest(http.MethodGet, u, nil)
+	var entryIDs []int64
+	resp, err := s.client.Do(req, &entryIDs)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return entryIDs, resp, nil
+}
+
+// GetEntries returns the entries matching a saved search, in order. A page
+// of zero returns the first page.
+func (s *SavedSearchesService) GetEntries(id int64, page int) ([]*Entry, *http.Response, error) {
+	req, err := s.client.NewRequest(http.MethodGet, savedSearchURL(id, true, page), nil)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// The response can be either an array of entry IDs or an array of Entry objects
-	// depending on the IncludeEntries parameter
-	var result interface{}
-	var resp *http.Response
+	var entries []*Entry
+	resp, err := s.client.Do(req, &entries)
+	if err != nil {
+		return nil, resp, err
+	}
 
-	if opts != nil && opts.IncludeEntries {
-		var entries []*Entry
-		resp, err = s.client.Do(req, &entries)
-		if err != nil {
-			return nil, resp, err
-		}
-		result = entries
-	} else {
-		var entryIDs []int64
-		resp, err = s.client.Do(req, &entryIDs)
-		if err != nil {
-			return nil, resp, err
-		}
-		result = entryIDs
+	return entries, resp, nil
+}
+
+// savedSearchURL returns the path of a saved search's results.
+func savedSearchURL(id int64, includeEntries bool, page int) string {
+	u := fmt.Sprintf("/v2/saved_searches/%d.json", id)
+	params := url.Values{}
+
+	if includeEntries {
+		params.Add("include_entries", "true")
+	}
+
+	if page > 0 {
+		params.Add("page", strconv.Itoa(page))
+	}
+
+	if len(params) > 0 {
+		u += "?" + params.Encode()
 	}
 
-	return result, resp, nil
+	return u
 }
 
 // CreateSavedSearchOptions specifies the parameters to the
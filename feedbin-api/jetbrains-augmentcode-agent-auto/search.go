@@ -0,0 +1,782 @@
+package feedbin
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ErrEmptySearchQuery is returned when a search query has no clauses.
+var ErrEmptySearchQuery = errors.New("empty search query")
+
+// Fields of an entry that can be searched.
+const (
+	searchFieldTitle   = "title"
+	searchFieldContent = "content"
+	searchFieldAuthor  = "author"
+)
+
+// searchFields are the indexed text fields and the weight of a match in each.
+var searchFields = map[string]float64{
+	searchFieldTitle:   2,
+	searchFieldContent: 1,
+	searchFieldAuthor:  1,
+}
+
+// SearchIndex is an in-memory inverted index over entries that evaluates
+// Feedbin's saved search query language offline. It is safe for concurrent
+// use.
+//
+// Read and starred state and the feeds in each tag are not part of an entry,
+// so they are set separately with SetUnread, SetStarred and SetTag.
+type SearchIndex struct {
+	mu       sync.RWMutex
+	entries  map[int64]*Entry
+	postings map[string]map[string]map[int64][]int
+	terms    map[int64]map[string][]string
+	unread   map[int64]bool
+	starred  map[int64]bool
+	tags     map[int64]map[int64]bool
+}
+
+// NewSearchIndex returns an empty search index.
+func NewSearchIndex() *SearchIndex {
+	ix := &SearchIndex{
+		entries:  make(map[int64]*Entry),
+		postings: make(map[string]map[string]map[int64][]int),
+		terms:    make(map[int64]map[string][]string),
+		unread:   make(map[int64]bool),
+		starred:  make(map[int64]bool),
+		tags:     make(map[int64]map[int64]bool),
+	}
+	for field := range searchFields {
+		ix.postings[field] = make(map[string]map[int64][]int)
+	}
+	return ix
+}
+
+// SearchResult is an entry matching a search and its relevance score.
+type SearchResult struct {
+	Entry *Entry
+	Score float64
+}
+
+// Add indexes entries, replacing any already indexed with the same ID.
+func (ix *SearchIndex) Add(entries ...*Entry) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for _, entry := range entries {
+		ix.remove(entry.ID)
+
+		content := entry.Content
+		if content == "" {
+			content = entry.Summary
+		}
+
+		fields := map[string]string{
+			searchFieldTitle:   entry.Title,
+			searchFieldContent: content,
+			searchFieldAuthor:  entry.Author,
+		}
+
+		terms := make(map[string][]string)
+		for field, text := range fields {
+			for pos, term := range searchTerms(text) {
+				postings := ix.postings[field][term]
+				if postings == nil {
+					postings = make(map[int64][]int)
+					ix.postings[field][term] = postings
+					terms[field] = append(terms[field], term)
+				} else if _, ok := postings[entry.ID]; !ok {
+					terms[field] = append(terms[field], term)
+				}
+				postings[entry.ID] = append(postings[entry.ID], pos)
+			}
+		}
+
+		ix.entries[entry.ID] = entry
+		ix.terms[entry.ID] = terms
+	}
+}
+
+// Remove removes entries from the index.
+func (ix *SearchIndex) Remove(ids ...int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for _, id := range ids {
+		ix.remove(id)
+	}
+}
+
+// remove removes an entry's postings. The caller must hold the write lock.
+func (ix *SearchIndex) remove(id int64) {
+	for field, terms := range ix.terms[id] {
+		for _, term := range terms {
+			delete(ix.postings[field][term], id)
+			if len(ix.postings[field][term]) == 0 {
+				delete(ix.postings[field], term)
+			}
+		}
+	}
+	delete(ix.terms, id)
+	delete(ix.entries, id)
+}
+
+// Len returns the number of indexed entries.
+func (ix *SearchIndex) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.entries)
+}
+
+// SetUnread sets the IDs of the unread entries, as returned by
+// UnreadEntriesService.List.
+func (ix *SearchIndex) SetUnread(ids []int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.unread = idSet(ids)
+}
+
+// SetStarred sets the IDs of the starred entries, as returned by
+// StarredEntriesService.List.
+func (ix *SearchIndex) SetStarred(ids []int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.starred = idSet(ids)
+}
+
+// SetTag sets the feeds in a tag, used to evaluate tag_id: clauses.
+func (ix *SearchIndex) SetTag(tagID int64, feedIDs []int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.tags[tagID] = idSet(feedIDs)
+}
+
+// Refresh loads the unread and starred entry IDs from the API.
+func (ix *SearchIndex) Refresh(client *Client) error {
+	unread, _, err := client.UnreadEntries.List()
+	if err != nil {
+		return err
+	}
+
+	starred, _, err := client.StarredEntries.List()
+	if err != nil {
+		return err
+	}
+
+	ix.SetUnread(unread)
+	ix.SetStarred(starred)
+	return nil
+}
+
+// Search evaluates a query against the index. Results are ordered by score,
+// then by publication date with the newest first.
+//
+// The query language supports terms, quoted phrases, title:, content: (or
+// body:) and author: field prefixes, exclusions with a leading - or NOT, OR
+// between clauses, parentheses, is:read, is:unread, is:starred,
+// is:unstarred, feed_id:, tag_id: and published: or created_at: dates and
+// ranges such as published:>=2024-01-01, published:[2024-01-01 TO
+// 2024-01-31] and published:>now-7d. Clauses not joined by OR must all match.
+func (ix *SearchIndex) Search(query string) ([]*SearchResult, error) {
+	q, err := ParseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return ix.Query(q), nil
+}
+
+// SavedSearch evaluates a saved search's query against the index.
+func (ix *SearchIndex) SavedSearch(search *SavedSearch) ([]*SearchResult, error) {
+	return ix.Search(search.Query)
+}
+
+// Query evaluates a parsed query against the index.
+func (ix *SearchIndex) Query(q *SearchQuery) []*SearchResult {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	scores := ix.evalQuery(q)
+
+	results := make([]*SearchResult, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, &SearchResult{Entry: ix.entries[id], Score: score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.Entry.Published.Equal(b.Entry.Published) {
+			return a.Entry.Published.After(b.Entry.Published)
+		}
+		return a.Entry.ID > b.Entry.ID
+	})
+
+	return results
+}
+
+// SearchQuery is a parsed search query: clauses grouped into alternatives
+// separated by OR.
+type SearchQuery struct {
+	groups [][]*searchClause
+}
+
+// searchClause is a single clause of a query.
+type searchClause struct {
+	negate bool
+	field  string
+	value  string
+	sub    *SearchQuery
+
+	// terms are the analyzed words of a text clause; more than one is
+	// matched as a phrase
+	terms []string
+
+	// from and to bound a date clause, to being exclusive
+	from, to time.Time
+}
+
+// ParseSearchQuery parses a query in Feedbin's search syntax. See
+// SearchIndex.Search for the supported syntax.
+func ParseSearchQuery(query string) (*SearchQuery, error) {
+	tokens, err := tokenizeSearch(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &searchParser{tokens: tokens}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in search query", p.tokens[p.pos].text)
+	}
+	if len(q.groups) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	return q, nil
+}
+
+// searchToken is a lexical token of a query.
+type searchToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeSearch splits a query into words, quoted phrases and parentheses.
+// A field prefix stays attached to its quoted or bracketed value.
+func tokenizeSearch(query string) ([]searchToken, error) {
+	var tokens []searchToken
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, searchToken{text: string(r)})
+			i++
+		default:
+			start := i
+			quoted := false
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+				switch runes[i] {
+				case '"':
+					end := indexRune(runes, i+1, '"')
+					if end < 0 {
+						return nil, errors.New("unterminated quote in search query")
+					}
+					quoted = true
+					i = end + 1
+				case '[', '{':
+					if i == start || runes[i-1] != ':' {
+						i++
+						continue
+					}
+					end := indexRune(runes, i+1, ']')
+					if alt := indexRune(runes, i+1, '}'); alt >= 0 && (end < 0 || alt < end) {
+						end = alt
+					}
+					if end < 0 {
+						return nil, errors.New("unterminated range in search query")
+					}
+					i = end + 1
+				default:
+					i++
+				}
+			}
+			tokens = append(tokens, searchToken{text: string(runes[start:i]), quoted: quoted})
+		}
+	}
+
+	return tokens, nil
+}
+
+// indexRune returns the index of r in runes at or after from, or -1.
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// searchParser is a recursive descent parser over query tokens.
+type searchParser struct {
+	tokens []searchToken
+	pos    int
+	depth  int
+}
+
+// parseQuery parses clauses up to the end of input or a closing parenthesis.
+func (p *searchParser) parseQuery() (*SearchQuery, error) {
+	q := &SearchQuery{}
+	var group []*searchClause
+	negate := false
+
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+
+		if !tok.quoted {
+			switch tok.text {
+			case ")":
+				if p.depth == 0 {
+					return nil, errors.New("unbalanced parenthesis in search query")
+				}
+				if negate {
+					return nil, errors.New("NOT without a clause in search query")
+				}
+				if len(group) > 0 {
+					q.groups = append(q.groups, group)
+				}
+				return q, nil
+			case "OR", "||":
+				p.pos++
+				if negate {
+					return nil, errors.New("NOT without a clause in search query")
+				}
+				if len(group) > 0 {
+					q.groups = append(q.groups, group)
+				}
+				group = nil
+				continue
+			case "AND", "&&":
+				p.pos++
+				continue
+			case "NOT":
+				p.pos++
+				negate = !negate
+				continue
+			case "(":
+				p.pos++
+				p.depth++
+				sub, err := p.parseQuery()
+				if err != nil {
+					return nil, err
+				}
+				if p.pos >= len(p.tokens) {
+					return nil, errors.New("unbalanced parenthesis in search query")
+				}
+				p.pos++
+				p.depth--
+				if len(sub.groups) > 0 {
+					group = append(group, &searchClause{negate: negate, sub: sub})
+				}
+				negate = false
+				continue
+			}
+		}
+
+		p.pos++
+		text := tok.text
+		if strings.HasPrefix(text, "-") && len(text) > 1 {
+			negate = !negate
+			text = text[1:]
+		} else if strings.HasPrefix(text, "+") && len(text) > 1 {
+			text = text[1:]
+		}
+
+		if text == "-" && p.pos < len(p.tokens) && p.tokens[p.pos].text == "(" {
+			negate = !negate
+			continue
+		}
+
+		clause, err := parseSearchClause(text)
+		if err != nil {
+			return nil, err
+		}
+		if clause != nil {
+			clause.negate = negate
+			group = append(group, clause)
+		}
+		negate = false
+	}
+
+	if p.depth > 0 {
+		return nil, errors.New("unbalanced parenthesis in search query")
+	}
+	if negate {
+		return nil, errors.New("NOT without a clause in search query")
+	}
+	if len(group) > 0 {
+		q.groups = append(q.groups, group)
+	}
+
+	return q, nil
+}
+
+// parseSearchClause parses a word, phrase or field:value token. It returns
+// nil for text without searchable words.
+func parseSearchClause(text string) (*searchClause, error) {
+	field, value := "", text
+	if i := strings.Index(text, ":"); i > 0 && !strings.HasPrefix(text, `"`) {
+		field, value = strings.ToLower(text[:i]), text[i+1:]
+	}
+
+	switch field {
+	case "title", "content", "body", "author":
+		if field == "body" {
+			field = searchFieldContent
+		}
+	case "is":
+		switch value = strings.ToLower(value); value {
+		case "read", "unread", "starred", "unstarred":
+			return &searchClause{field: field, value: value}, nil
+		}
+		return nil, fmt.Errorf("unknown search filter is:%s", value)
+	case "feed_id", "tag_id":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s in search query: %q", field, value)
+		}
+		return &searchClause{field: field, value: value}, nil
+	case "published", "created_at":
+		from, to, err := parseSearchRange(value, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in search query: %w", field, err)
+		}
+		return &searchClause{field: field, value: value, from: from, to: to}, nil
+	default:
+		// Not a known field, so the colon is part of the text
+		field, value = "", text
+	}
+
+	terms := searchTerms(strings.Trim(value, `"`))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	return &searchClause{field: field, value: value, terms: terms}, nil
+}
+
+// searchDatePattern matches relative dates such as now-7d.
+var searchDatePattern = regexp.MustCompile(`^now(?:([+-])(\d+)([mhdwMy]))?$`)
+
+// parseSearchRange parses a date or date range into an inclusive start and
+// an exclusive end. A zero time leaves that side open.
+func parseSearchRange(value string, now time.Time) (time.Time, time.Time, error) {
+	var from, to time.Time
+
+	if len(value) > 1 && (value[0] == '[' || value[0] == '{') {
+		closing := value[len(value)-1]
+		if closing != ']' && closing != '}' {
+			return from, to, fmt.Errorf("unterminated range %q", value)
+		}
+
+		parts := strings.Fields(value[1 : len(value)-1])
+		if len(parts) != 3 || !strings.EqualFold(parts[1], "TO") {
+			return from, to, fmt.Errorf("invalid range %q", value)
+		}
+
+		if parts[0] != "*" {
+			start, end, err := parseSearchDate(parts[0], now)
+			if err != nil {
+				return from, to, err
+			}
+			from = start
+			if value[0] == '{' {
+				from = end
+			}
+		}
+
+		if parts[2] != "*" {
+			start, end, err := parseSearchDate(parts[2], now)
+			if err != nil {
+				return from, to, err
+			}
+			to = end
+			if closing == '}' {
+				to = start
+			}
+		}
+
+		return from, to, nil
+	}
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(value, prefix) {
+			op, value = prefix, value[len(prefix):]
+			break
+		}
+	}
+
+	start, end, err := parseSearchDate(value, now)
+	if err != nil {
+		return from, to, err
+	}
+
+	switch op {
+	case ">=":
+		from = start
+	case ">":
+		from = end
+	case "<=":
+		to = end
+	case "<":
+		to = start
+	default:
+		from, to = start, end
+	}
+
+	return from, to, nil
+}
+
+// parseSearchDate parses a date and returns the span it covers: a whole day
+// for dates without a time, or an instant otherwise.
+func parseSearchDate(value string, now time.Time) (time.Time, time.Time, error) {
+	if m := searchDatePattern.FindStringSubmatch(value); m != nil {
+		t := now
+		if m[1] != "" {
+			n, _ := strconv.Atoi(m[2])
+			if m[1] == "-" {
+				n = -n
+			}
+			switch m[3] {
+			case "m":
+				t = t.Add(time.Duration(n) * time.Minute)
+			case "h":
+				t = t.Add(time.Duration(n) * time.Hour)
+			case "d":
+				t = t.AddDate(0, 0, n)
+			case "w":
+				t = t.AddDate(0, 0, 7*n)
+			case "M":
+				t = t.AddDate(0, n, 0)
+			case "y":
+				t = t.AddDate(n, 0, 0)
+			}
+		}
+		return t, t.Add(time.Nanosecond), nil
+	}
+
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, t.AddDate(0, 0, 1), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, t.Add(time.Nanosecond), nil
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// evalQuery returns the score of every entry matching q.
+func (ix *SearchIndex) evalQuery(q *SearchQuery) map[int64]float64 {
+	scores := make(map[int64]float64)
+	for _, group := range q.groups {
+		for id, score := range ix.evalGroup(group) {
+			scores[id] += score
+		}
+	}
+	return scores
+}
+
+// evalGroup returns the score of every entry matching all clauses of a group.
+func (ix *SearchIndex) evalGroup(group []*searchClause) map[int64]float64 {
+	var scores map[int64]float64
+	for _, clause := range group {
+		if clause.negate {
+			continue
+		}
+
+		matches := ix.evalClause(clause)
+		if scores == nil {
+			scores = matches
+			continue
+		}
+
+		for id, score := range scores {
+			if match, ok := matches[id]; ok {
+				scores[id] = score + match
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	// A group of exclusions only matches everything else
+	if scores == nil {
+		scores = make(map[int64]float64, len(ix.entries))
+		for id := range ix.entries {
+			scores[id] = 0
+		}
+	}
+
+	for _, clause := range group {
+		if !clause.negate {
+			continue
+		}
+		for id := range ix.evalClause(clause) {
+			delete(scores, id)
+		}
+	}
+
+	return scores
+}
+
+// evalClause returns the score of every entry matching a clause, ignoring
+// its negation.
+func (ix *SearchIndex) evalClause(clause *searchClause) map[int64]float64 {
+	if clause.sub != nil {
+		return ix.evalQuery(clause.sub)
+	}
+
+	matches := make(map[int64]float64)
+	switch clause.field {
+	case "is":
+		for id := range ix.entries {
+			var match bool
+			switch clause.value {
+			case "read":
+				match = !ix.unread[id]
+			case "unread":
+				match = ix.unread[id]
+			case "starred":
+				match = ix.starred[id]
+			case "unstarred":
+				match = !ix.starred[id]
+			}
+			if match {
+				matches[id] = 0
+			}
+		}
+	case "feed_id":
+		feedID, _ := strconv.ParseInt(clause.value, 10, 64)
+		for id, entry := range ix.entries {
+			if entry.FeedID == feedID {
+				matches[id] = 0
+			}
+		}
+	case "tag_id":
+		tagID, _ := strconv.ParseInt(clause.value, 10, 64)
+		feeds := ix.tags[tagID]
+		for id, entry := range ix.entries {
+			if feeds[entry.FeedID] {
+				matches[id] = 0
+			}
+		}
+	case "published", "created_at":
+		for id, entry := range ix.entries {
+			t := entry.Published
+			if clause.field == "created_at" {
+				t = entry.CreatedAt
+			}
+			if (clause.from.IsZero() || !t.Before(clause.from)) && (clause.to.IsZero() || t.Before(clause.to)) {
+				matches[id] = 0
+			}
+		}
+	default:
+		for field, weight := range searchFields {
+			if clause.field != "" && clause.field != field {
+				continue
+			}
+			for id, score := range ix.matchText(field, clause.terms) {
+				matches[id] += weight * score
+			}
+		}
+	}
+
+	return matches
+}
+
+// matchText scores the entries whose field contains terms in order, using
+// TF-IDF summed over the terms.
+func (ix *SearchIndex) matchText(field string, terms []string) map[int64]float64 {
+	postings := make([]map[int64][]int, len(terms))
+	for i, term := range terms {
+		postings[i] = ix.postings[field][term]
+		if len(postings[i]) == 0 {
+			return nil
+		}
+	}
+
+	idf := 0.0
+	for _, p := range postings {
+		idf += math.Log(1 + float64(len(ix.entries))/float64(len(p)))
+	}
+
+	matches := make(map[int64]float64)
+	for id, positions := range postings[0] {
+		count := 0
+		for _, pos := range positions {
+			if phraseAt(postings, id, pos) {
+				count++
+			}
+		}
+		if count > 0 {
+			matches[id] = (1 + math.Log(float64(count))) * idf
+		}
+	}
+
+	return matches
+}
+
+// phraseAt reports whether the phrase of postings occurs in an entry
+// starting at pos.
+func phraseAt(postings []map[int64][]int, id int64, pos int) bool {
+	for i := 1; i < len(postings); i++ {
+		positions := postings[i][id]
+		j := sort.SearchInts(positions, pos+i)
+		if j == len(positions) || positions[j] != pos+i {
+			return false
+		}
+	}
+	return true
+}
+
+// searchTagPattern matches HTML tags, which are not indexed.
+var searchTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// searchTerms returns the lower-cased words of text, without HTML markup.
+func searchTerms(text string) []string {
+	text = html.UnescapeString(searchTagPattern.ReplaceAllString(text, " "))
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// idSet returns a set of IDs.
+func idSet(ids []int64) map[int64]bool {
+	set := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,96 @@
+package feedbin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestSearchIndex() *SearchIndex {
+	ix := NewSearchIndex()
+	ix.Add(
+		&Entry{ID: 1, FeedID: 10, Title: "Go generics explained", Content: "<p>Type parameters in Go.</p>", Published: time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)},
+		&Entry{ID: 2, FeedID: 10, Title: "Rust ownership", Content: "Borrowing and lifetimes, compared to Go.", Published: time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC)},
+		&Entry{ID: 3, FeedID: 20, Title: "Weekly links", Summary: "Parameters of type, and type parameters.", Author: "Jane Doe", Published: time.Date(2024, 2, 5, 12, 0, 0, 0, time.UTC)},
+		&Entry{ID: 4, FeedID: 30, Title: "Cooking pasta", Content: "Salt the water.", Published: time.Date(2024, 2, 15, 12, 0, 0, 0, time.UTC)},
+	)
+	ix.SetUnread([]int64{1, 3, 4})
+	ix.SetStarred([]int64{2})
+	ix.SetTag(5, []int64{10, 20})
+	return ix
+}
+
+func searchIDs(t *testing.T, ix *SearchIndex, query string) []int64 {
+	t.Helper()
+
+	results, err := ix.Search(query)
+	if err != nil {
+		t.Fatalf("Search(%q) returned error: %v", query, err)
+	}
+
+	ids := []int64{}
+	for _, result := range results {
+		ids = append(ids, result.Entry.ID)
+	}
+	return ids
+}
+
+func TestSearchIndex_Search(t *testing.T) {
+	ix := newTestSearchIndex()
+
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{"go", []int64{1, 2}},
+		{`"type parameters"`, []int64{3, 1}},
+		{"title:go", []int64{1}},
+		{"body:go", []int64{2, 1}},
+		{"go -rust", []int64{1}},
+		{"go NOT title:rust", []int64{1}},
+		{"rust OR pasta", []int64{4, 2}},
+		{"is:unread", []int64{4, 3, 1}},
+		{"is:starred", []int64{2}},
+		{"go is:unread", []int64{1}},
+		{"feed_id:10", []int64{2, 1}},
+		{"tag_id:5 is:unread", []int64{3, 1}},
+		{"author:jane", []int64{3}},
+		{"published:>=2024-02-01", []int64{4, 3}},
+		{"published:<=2024-01-20", []int64{2, 1}},
+		{"published:[2024-01-15 TO 2024-02-05]", []int64{3, 2}},
+		{"-is:unread", []int64{2}},
+		{"(rust OR pasta) -is:starred", []int64{4}},
+	}
+
+	for _, tt := range tests {
+		if got := searchIDs(t, ix, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchIndex_Remove(t *testing.T) {
+	ix := newTestSearchIndex()
+	ix.Remove(1)
+
+	if got := searchIDs(t, ix, "go"); !reflect.DeepEqual(got, []int64{2}) {
+		t.Errorf("Search after Remove = %v, want %v", got, []int64{2})
+	}
+
+	// Re-adding an entry replaces its indexed text
+	ix.Add(&Entry{ID: 2, Title: "Zig comptime"})
+	if got := searchIDs(t, ix, "rust"); len(got) != 0 {
+		t.Errorf("Search for replaced text = %v, want none", got)
+	}
+	if ix.Len() != 3 {
+		t.Errorf("Len = %v, want %v", ix.Len(), 3)
+	}
+}
+
+func TestParseSearchQuery_Errors(t *testing.T) {
+	for _, query := range []string{"", `"open`, "(go", "go)", "is:maybe", "feed_id:x", "published:>yesterday"} {
+		if _, err := ParseSearchQuery(query); err == nil {
+			t.Errorf("ParseSearchQuery(%q) should have returned an error", query)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package client
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChangeResource is the kind of resource a change applies to
+type ChangeResource string
+
+const (
+	ChangeSubscription ChangeResource = "subscription"
+	ChangeTagging      ChangeResource = "tagging"
+	ChangeEntry        ChangeResource = "entry"
+	ChangeUnread       ChangeResource = "unread"  // Added means the entry became unread, removed that it was read
+	ChangeStarred      ChangeResource = "starred" // Added means the entry was starred, removed that it was unstarred
+)
+
+// ChangeKind is the kind of a change
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change is a single change to the user's Feedbin data. ID is the ID of the
+// subscription, tagging or entry; for unread and starred changes it is the
+// entry ID. The resource itself is set for added and modified subscriptions,
+// added taggings and added and modified entries
+type Change struct {
+	Resource     ChangeResource `json:"resource"`
+	Kind         ChangeKind     `json:"kind"`
+	ID           int64          `json:"id"`
+	Subscription *Subscription  `json:"subscription,omitempty"`
+	Tagging      *Tagging       `json:"tagging,omitempty"`
+	Entry        *Entry         `json:"entry,omitempty"`
+}
+
+// ChangeSet is the result of Client.Changes
+type ChangeSet struct {
+	// Changes ordered by resource (subscriptions, taggings, entries, unread
+	// and starred state), then removals before modifications and additions,
+	// then by ID
+	Changes []Change
+
+	// Cursor to pass to the next call of Client.Changes
+	Cursor *ChangeCursor
+}
+
+// ChangeCursor is the state of the previous call to Client.Changes. It can be
+// stored as JSON between calls. Entries created after Since are reported as
+// added, and entries not in Updated that are now in the updated entries list
+// as modified; the other changes are computed by comparing the current
+// subscriptions, taggings and unread and starred entry IDs with the ones in
+// the cursor. Since is a server time, so the client clock does not matter
+type ChangeCursor struct {
+	Since         time.Time               `json:"since"`
+	Subscriptions map[int64]*Subscription `json:"subscriptions"`
+	Taggings      []int64                 `json:"taggings"`
+	Unread        []int64                 `json:"unread"`
+	Starred       []int64                 `json:"starred"`
+	Updated       []int64                 `json:"updated"`
+}
+
+// changeResourceOrder is the order of resources in a ChangeSet
+var changeResourceOrder = map[ChangeResource]int{
+	ChangeSubscription: 0,
+	ChangeTagging:      1,
+	ChangeEntry:        2,
+	ChangeUnread:       3,
+	ChangeStarred:      4,
+}
+
+// changeKindOrder is the order of change kinds within a resource
+var changeKindOrder = map[ChangeKind]int{
+	ChangeRemoved:  0,
+	ChangeModified: 1,
+	ChangeAdded:    2,
+}
+
+// Changes returns everything that changed since the previous call, fetching
+// subscriptions, taggings, new entries and unread, starred and updated entry
+// IDs concurrently, then the modified entries. Only entries are fetched with
+// since: the since parameter of the other lists filters by creation time, so
+// it cannot show removals, renames or read and starred state changes, and
+// those lists are fetched in full and compared with the cursor instead.
+//
+// With a nil cursor, all current subscriptions, taggings and unread and
+// starred entries are reported as added but no entries are listed, and the
+// returned cursor reports entries created from then on, as of the server's
+// Date header; use a cursor with only Since set to include entries created
+// after that time. If any request fails, the error is returned and the
+// cursor should be reused for the next call
+func (c *Client) Changes(since *ChangeCursor) (*ChangeSet, error) {
+	if since == nil {
+		since = &ChangeCursor{}
+	}
+	start := time.Now().UTC()
+
+	var (
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		firstErr      error
+		subscriptions []*Subscription
+		taggings      []*Tagging
+		entries       []*Entry
+		unread        []int64
+		starred       []int64
+		updated       []int64
+		serverNow     time.Time
+	)
+
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	run(func() (err error) {
+		subscriptions, err = c.Subscriptions.List(nil)
+		return err
+	})
+	run(func() (err error) {
+		taggings, err = c.Taggings.List()
+		return err
+	})
+	run(func() (err error) {
+		unread, serverNow, err = c.UnreadEntries.list()
+		return err
+	})
+	run(func() (err error) {
+		starred, err = c.StarredEntries.List()
+		return err
+	})
+	run(func() (err error) {
+		updated, err = c.UpdatedEntries.List()
+		return err
+	})
+	if !since.Since.IsZero() {
+		run(func() (err error) {
+			entries, err = c.Entries.listAllSince(since.Since)
+			return err
+		})
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	next := &ChangeCursor{
+		Since:         since.Since,
+		Subscriptions: make(map[int64]*Subscription, len(subscriptions)),
+		Taggings:      make([]int64, 0, len(taggings)),
+		Unread:        sortedIDs(unread),
+		Starred:       sortedIDs(starred),
+		Updated:       sortedIDs(updated),
+	}
+
+	var changes []Change
+
+	// Subscriptions
+	for _, s := range subscriptions {
+		next.Subscriptions[s.ID] = s
+
+		previous, ok := since.Subscriptions[s.ID]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Resource: ChangeSubscription, Kind: ChangeAdded, ID: s.ID, Subscription: s})
+		case subscriptionModified(previous, s):
+			changes = append(changes, Change{Resource: ChangeSubscription, Kind: ChangeModified, ID: s.ID, Subscription: s})
+		}
+	}
+	for id := range since.Subscriptions {
+		if _, ok := next.Subscriptions[id]; !ok {
+			changes = append(changes, Change{Resource: ChangeSubscription, Kind: ChangeRemoved, ID: id})
+		}
+	}
+
+	// Taggings
+	previousTaggings := int64Set(since.Taggings)
+	for _, t := range taggings {
+		next.Taggings = append(next.Taggings, t.ID)
+		if !previousTaggings[t.ID] {
+			changes = append(changes, Change{Resource: ChangeTagging, Kind: ChangeAdded, ID: t.ID, Tagging: t})
+		}
+	}
+	next.Taggings = sortedIDs(next.Taggings)
+	changes = append(changes, removedIDs(ChangeTagging, since.Taggings, next.Taggings)...)
+
+	// Entries
+	added := make(map[int64]bool, len(entries))
+	for _, e := range entries {
+		added[e.ID] = true
+		changes = append(changes, Change{Resource: ChangeEntry, Kind: ChangeAdded, ID: e.ID, Entry: e})
+		if e.CreatedAt.After(next.Since) {
+			next.Since = e.CreatedAt
+		}
+	}
+
+	if !since.Since.IsZero() {
+		previousUpdated := int64Set(since.Updated)
+		var modifiedIDs []int64
+		for _, id := range next.Updated {
+			if !previousUpdated[id] && !added[id] {
+				modifiedIDs = append(modifiedIDs, id)
+			}
+		}
+
+		modified, err := c.Entries.listByIDs(modifiedIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range modified {
+			changes = append(changes, Change{Resource: ChangeEntry, Kind: ChangeModified, ID: e.ID, Entry: e})
+		}
+	}
+
+	// A new cursor starts at the server time, falling back to the client
+	// clock when the response has no Date header
+	if next.Since.IsZero() {
+		next.Since = serverNow
+	}
+	if next.Since.IsZero() {
+		next.Since = start
+	}
+
+	// Unread and starred state
+	changes = append(changes, diffIDs(ChangeUnread, since.Unread, next.Unread)...)
+	changes = append(changes, diffIDs(ChangeStarred, since.Starred, next.Starred)...)
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Resource != b.Resource {
+			return changeResourceOrder[a.Resource] < changeResourceOrder[b.Resource]
+		}
+		if a.Kind != b.Kind {
+			return changeKindOrder[a.Kind] < changeKindOrder[b.Kind]
+		}
+		return a.ID < b.ID
+	})
+
+	return &ChangeSet{Changes: changes, Cursor: next}, nil
+}
+
+// listAllSince returns all entries created after since, following pagination
+func (s *EntriesService) listAllSince(since time.Time) ([]*Entry, error) {
+	var all []*Entry
+
+	for page := 1; ; page++ {
+		entries, pagination, err := s.List(&EntryListOptions{
+			Since:   since,
+			Page:    page,
+			PerPage: 100,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, entries...)
+
+		if len(entries) == 0 || pagination.NextLink == "" {
+			return all, nil
+		}
+	}
+}
+
+// listByIDs returns the entries with the given IDs, 100 per request
+func (s *EntriesService) listByIDs(ids []int64) ([]*Entry, error) {
+	var all []*Entry
+
+	for start := 0; start < len(ids); start += 100 {
+		end := start + 100
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		entries, _, err := s.List(&EntryListOptions{Ids: ids[start:end]})
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, entries...)
+	}
+
+	return all, nil
+}
+
+// serverTime returns the time of a response from its Date header, or the
+// zero time if it has none
+func serverTime(resp *http.Response) time.Time {
+	if resp == nil {
+		return time.Time{}
+	}
+
+	t, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t.UTC()
+}
+
+// subscriptionModified reports whether the user visible fields of a
+// subscription changed
+func subscriptionModified(previous, current *Subscription) bool {
+	return previous.Title != current.Title ||
+		previous.FeedURL != current.FeedURL ||
+		previous.SiteURL != current.SiteURL
+}
+
+// diffIDs returns the added and removed changes between two sets of IDs
+func diffIDs(resource ChangeResource, previous, current []int64) []Change {
+	var changes []Change
+
+	previousSet := int64Set(previous)
+	for _, id := range current {
+		if !previousSet[id] {
+			changes = append(changes, Change{Resource: resource, Kind: ChangeAdded, ID: id})
+		}
+	}
+
+	return append(changes, removedIDs(resource, previous, current)...)
+}
+
+// removedIDs returns a removed change for each ID in previous that is not in
+// current
+func removedIDs(resource ChangeResource, previous, current []int64) []Change {
+	var changes []Change
+
+	currentSet := int64Set(current)
+	for _, id := range previous {
+		if !currentSet[id] {
+			changes = append(changes, Change{Resource: resource, Kind: ChangeRemoved, ID: id})
+		}
+	}
+
+	return changes
+}
+
+// sortedIDs returns a sorted copy of ids
+func sortedIDs(ids []int64) []int64 {
+	sorted := append([]int64{}, ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
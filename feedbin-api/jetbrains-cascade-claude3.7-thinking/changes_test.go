@@ -0,0 +1,221 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeChangesServer serves the lists Changes reads, with a server clock that
+// differs from the client's
+type fakeChangesServer struct {
+	mu            sync.Mutex
+	now           time.Time
+	subscriptions []*Subscription
+	taggings      []*Tagging
+	unread        []int64
+	starred       []int64
+	updated       []int64
+	entries       []*Entry
+	since         []string
+	idRequests    int
+	fail          string
+}
+
+func (s *fakeChangesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Date", s.now.Format(http.TimeFormat))
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	if path == s.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	switch path {
+	case "subscriptions.json":
+		json.NewEncoder(w).Encode(s.subscriptions)
+	case "taggings.json":
+		json.NewEncoder(w).Encode(s.taggings)
+	case "unread_entries.json":
+		json.NewEncoder(w).Encode(s.unread)
+	case "starred_entries.json":
+		json.NewEncoder(w).Encode(s.starred)
+	case "updated_entries.json":
+		json.NewEncoder(w).Encode(s.updated)
+	case "entries.json":
+		json.NewEncoder(w).Encode(s.listEntries(r))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// listEntries returns the entries with the requested IDs or created after
+// since
+func (s *fakeChangesServer) listEntries(r *http.Request) []*Entry {
+	entries := []*Entry{}
+
+	if ids := r.URL.Query().Get("ids"); ids != "" {
+		s.idRequests++
+		for _, id := range strings.Split(ids, ",") {
+			for _, e := range s.entries {
+				if strconv.FormatInt(e.ID, 10) == id {
+					entries = append(entries, e)
+				}
+			}
+		}
+		return entries
+	}
+
+	s.since = append(s.since, r.URL.Query().Get("since"))
+	since, _ := time.Parse(time.RFC3339Nano, r.URL.Query().Get("since"))
+	for _, e := range s.entries {
+		if e.CreatedAt.After(since) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func newChangesTestClient(t *testing.T, server *fakeChangesServer) *Client {
+	s := httptest.NewServer(server)
+	t.Cleanup(s.Close)
+
+	c, err := NewClient("user", "pass", WithBaseURL(s.URL+"/v2/"))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	return c
+}
+
+func changeKeys(set *ChangeSet) []string {
+	var keys []string
+	for _, c := range set.Changes {
+		keys = append(keys, string(c.Resource)+" "+string(c.Kind)+" "+strconv.FormatInt(c.ID, 10))
+	}
+	return keys
+}
+
+func TestChanges(t *testing.T) {
+	serverNow := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := &fakeChangesServer{
+		now:           serverNow,
+		subscriptions: []*Subscription{{ID: 1, FeedID: 10, Title: "Go Blog"}, {ID: 2, FeedID: 20, Title: "News"}},
+		taggings:      []*Tagging{{ID: 5, FeedID: 10, Name: "Go"}},
+		unread:        []int64{100, 101},
+		starred:       []int64{100},
+		updated:       []int64{90},
+		entries:       []*Entry{{ID: 90, CreatedAt: serverNow.Add(-time.Hour)}},
+	}
+	c := newChangesTestClient(t, server)
+
+	// A new cursor reports the current state but no entries, and starts at
+	// the server time rather than the client's
+	first, err := c.Changes(nil)
+	if err != nil {
+		t.Fatalf("Changes returned error: %v", err)
+	}
+
+	want := []string{
+		"subscription added 1", "subscription added 2", "tagging added 5",
+		"unread added 100", "unread added 101", "starred added 100",
+	}
+	if got := changeKeys(first); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("First Changes = %v, want %v", got, want)
+	}
+	if !first.Cursor.Since.Equal(serverNow) {
+		t.Errorf("Cursor Since = %v, want the server time %v", first.Cursor.Since, serverNow)
+	}
+
+	// Round trip the cursor through JSON as a caller would
+	data, _ := json.Marshal(first.Cursor)
+	var cursor ChangeCursor
+	json.Unmarshal(data, &cursor)
+
+	created := serverNow.Add(time.Minute)
+	server.mu.Lock()
+	server.subscriptions = []*Subscription{{ID: 1, FeedID: 10, Title: "The Go Blog"}, {ID: 3, FeedID: 30, Title: "Rust"}}
+	server.taggings = nil
+	server.unread = []int64{101, 102}
+	server.starred = []int64{100, 102}
+	server.updated = []int64{90, 91, 102}
+	server.entries = append(server.entries,
+		&Entry{ID: 91, CreatedAt: serverNow.Add(-2 * time.Hour)},
+		&Entry{ID: 102, CreatedAt: created})
+	server.mu.Unlock()
+
+	second, err := c.Changes(&cursor)
+	if err != nil {
+		t.Fatalf("Changes returned error: %v", err)
+	}
+
+	want = []string{
+		"subscription removed 2", "subscription modified 1", "subscription added 3",
+		"tagging removed 5",
+		"entry modified 91", "entry added 102",
+		"unread removed 100", "unread added 102",
+		"starred added 102",
+	}
+	if got := changeKeys(second); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Second Changes = %v, want %v", got, want)
+	}
+
+	for _, change := range second.Changes {
+		if change.Resource == ChangeEntry && change.Entry == nil {
+			t.Errorf("Change %s %d has no entry", change.Kind, change.ID)
+		}
+	}
+
+	if len(server.since) != 1 || server.since[0] != serverNow.Format(time.RFC3339Nano) {
+		t.Errorf("Entries requested since %v, want the cursor time %v", server.since, serverNow)
+	}
+	if !second.Cursor.Since.Equal(created) {
+		t.Errorf("Cursor Since = %v, want the newest entry's created_at %v", second.Cursor.Since, created)
+	}
+
+	// Nothing changed since, and updated entries are reported once
+	third, err := c.Changes(second.Cursor)
+	if err != nil {
+		t.Fatalf("Changes returned error: %v", err)
+	}
+	if len(third.Changes) != 0 {
+		t.Errorf("Third Changes = %v, want none", changeKeys(third))
+	}
+	if server.idRequests != 1 {
+		t.Errorf("Entries were fetched by ID %d times, want 1", server.idRequests)
+	}
+}
+
+func TestChangesFetchesModifiedEntriesInBatches(t *testing.T) {
+	server := &fakeChangesServer{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for id := int64(1); id <= 250; id++ {
+		server.updated = append(server.updated, id)
+		server.entries = append(server.entries, &Entry{ID: id})
+	}
+	c := newChangesTestClient(t, server)
+
+	set, err := c.Changes(&ChangeCursor{Since: server.now})
+	if err != nil {
+		t.Fatalf("Changes returned error: %v", err)
+	}
+
+	if len(set.Changes) != 250 || server.idRequests != 3 {
+		t.Errorf("Changes reported %d modified entries in %d requests, want 250 in 3", len(set.Changes), server.idRequests)
+	}
+}
+
+func TestChangesReturnsErrors(t *testing.T) {
+	server := &fakeChangesServer{now: time.Now(), fail: "updated_entries.json"}
+	c := newChangesTestClient(t, server)
+
+	if _, err := c.Changes(nil); err == nil {
+		t.Error("Expected an error when a list cannot be fetched")
+	}
+}
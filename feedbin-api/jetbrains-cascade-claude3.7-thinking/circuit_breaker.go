@@ -0,0 +1,351 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned when a request is rejected because the circuit
+// breaker is open. Errors returned by the client can be matched against it
+// with errors.Is
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError occurs when a request fails fast because the Feedbin API
+// is considered unavailable
+type CircuitOpenError struct {
+	// Time of the next probe of the API
+	RetryAt time.Time
+
+	// The failure that opened the circuit
+	LastError error
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open until %v: %v",
+		e.RetryAt.Format(time.RFC3339), e.LastError)
+}
+
+// Is reports whether target is ErrCircuitOpen
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen rejects all requests until the API is probed again
+	CircuitOpen
+
+	// CircuitHalfOpen lets a single trial request through to decide whether
+	// to close or reopen the circuit. With a probe, the trial is the probe
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitBreakerOptions configures the circuit breaker of a Client
+type CircuitBreakerOptions struct {
+	FailureThreshold int           // Consecutive failures that open the circuit (default 5)
+	OpenTimeout      time.Duration // Time between probes while the circuit is open (default 30s)
+}
+
+// HealthState is a snapshot of the circuit breaker state
+type HealthState struct {
+	State               CircuitState
+	ConsecutiveFailures int
+	LastError           error     // Last failure, if any
+	LastFailure         time.Time // Time of the last failure
+	OpenedAt            time.Time // Time the circuit was last opened
+	NextProbe           time.Time // Time of the next probe while open
+}
+
+// StateChangeFunc is called when the circuit breaker changes state
+type StateChangeFunc func(from, to CircuitState, health HealthState)
+
+// CircuitBreaker tracks the health of the Feedbin API. Server errors (5xx),
+// timeouts and rate limit errors count as failures; after FailureThreshold
+// consecutive failures the circuit opens and requests fail fast with a
+// CircuitOpenError. While open, the API is probed with an authentication
+// request every OpenTimeout. The probe is the half-open trial, so other
+// requests keep failing fast until it succeeds and closes the circuit
+type CircuitBreaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	probe            func()
+
+	mu          sync.Mutex
+	state       CircuitState
+	failures    int
+	lastError   error
+	lastFailure time.Time
+	openedAt    time.Time
+	nextProbe   time.Time
+	trial       bool
+	timer       *time.Timer
+	listeners   []StateChangeFunc
+}
+
+// newCircuitBreaker creates a circuit breaker from opts
+func newCircuitBreaker(opts *CircuitBreakerOptions, probe func()) *CircuitBreaker {
+	b := &CircuitBreaker{
+		failureThreshold: defaultFailureThreshold,
+		openTimeout:      defaultOpenTimeout,
+		probe:            probe,
+	}
+
+	if opts != nil {
+		if opts.FailureThreshold > 0 {
+			b.failureThreshold = opts.FailureThreshold
+		}
+		if opts.OpenTimeout > 0 {
+			b.openTimeout = opts.OpenTimeout
+		}
+	}
+
+	return b
+}
+
+// WithCircuitBreaker enables a circuit breaker for all API requests
+func WithCircuitBreaker(opts *CircuitBreakerOptions) ClientOption {
+	return func(c *Client) error {
+		c.breaker = newCircuitBreaker(opts, c.probe)
+		return nil
+	}
+}
+
+// probe sends the authentication request that decides whether an open
+// circuit closes. It bypasses allow, since it holds the half-open trial
+func (c *Client) probe() {
+	c.breaker.runProbe(func() error {
+		req, err := c.newRequest("GET", "authentication.json", nil)
+		if err != nil {
+			return err
+		}
+
+		_, err = c.send(req, nil)
+		return err
+	})
+}
+
+// CircuitBreaker returns the client's circuit breaker, or nil if it was
+// created without WithCircuitBreaker
+func (c *Client) CircuitBreaker() *CircuitBreaker {
+	return c.breaker
+}
+
+// Health returns the current health state of the API as seen by the client.
+// Without a circuit breaker the state is always closed
+func (c *Client) Health() HealthState {
+	if c.breaker == nil {
+		return HealthState{State: CircuitClosed}
+	}
+	return c.breaker.Health()
+}
+
+// State returns the current state of the circuit
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Health returns a snapshot of the circuit breaker state
+func (b *CircuitBreaker) Health() HealthState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.health()
+}
+
+// OnStateChange registers a listener that is called after every state
+// change. Listeners are called synchronously, in registration order, from the
+// goroutine whose request or probe caused the change
+func (b *CircuitBreaker) OnStateChange(fn StateChangeFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, fn)
+}
+
+// Reset closes the circuit and clears the failure count
+func (b *CircuitBreaker) Reset() {
+	b.mu.Lock()
+	b.failures = 0
+	b.trial = false
+	notify := b.transition(CircuitClosed)
+	b.mu.Unlock()
+
+	notify()
+}
+
+// allow reports whether a request may be sent. Without a probe, an open
+// circuit whose timeout has passed moves to half-open and the request is the
+// trial
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+
+	notify := func() {}
+	if b.state == CircuitOpen && b.probe == nil && !time.Now().Before(b.nextProbe) {
+		notify = b.transition(CircuitHalfOpen)
+	}
+
+	var err error
+	switch b.state {
+	case CircuitOpen:
+		err = &CircuitOpenError{RetryAt: b.nextProbe, LastError: b.lastError}
+	case CircuitHalfOpen:
+		if b.trial {
+			err = &CircuitOpenError{RetryAt: b.nextProbe, LastError: b.lastError}
+		} else {
+			b.trial = true
+		}
+	}
+	b.mu.Unlock()
+
+	notify()
+	return err
+}
+
+// runProbe moves an open circuit to half-open, taking the trial, and
+// records the result of send. It does nothing if the circuit was closed in
+// the meantime
+func (b *CircuitBreaker) runProbe(send func() error) {
+	b.mu.Lock()
+	if b.state != CircuitOpen {
+		b.mu.Unlock()
+		return
+	}
+	notify := b.transition(CircuitHalfOpen)
+	b.trial = true
+	b.mu.Unlock()
+
+	notify()
+	b.record(send())
+}
+
+// record updates the circuit with the result of a request
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+
+	failed := isCircuitFailure(err)
+	if failed {
+		b.failures++
+		b.lastError = err
+		b.lastFailure = time.Now()
+	} else {
+		b.failures = 0
+	}
+
+	notify := func() {}
+	switch b.state {
+	case CircuitClosed:
+		if failed && b.failures >= b.failureThreshold {
+			notify = b.transition(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		b.trial = false
+		if failed {
+			notify = b.transition(CircuitOpen)
+		} else {
+			notify = b.transition(CircuitClosed)
+		}
+	}
+	b.mu.Unlock()
+
+	notify()
+}
+
+// transition changes the state and returns a function that notifies the
+// listeners. It must be called with b.mu held, and the returned function
+// without it
+func (b *CircuitBreaker) transition(to CircuitState) func() {
+	from := b.state
+	if from == to {
+		return func() {}
+	}
+
+	b.state = to
+
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+
+	if to == CircuitOpen {
+		b.openedAt = time.Now()
+		b.nextProbe = b.openedAt.Add(b.openTimeout)
+		if b.probe != nil {
+			b.timer = time.AfterFunc(b.openTimeout, b.probe)
+		}
+	} else {
+		b.nextProbe = time.Time{}
+	}
+
+	health := b.health()
+	listeners := append([]StateChangeFunc(nil), b.listeners...)
+
+	return func() {
+		for _, fn := range listeners {
+			fn(from, to, health)
+		}
+	}
+}
+
+// health returns a snapshot of the state. It must be called with b.mu held
+func (b *CircuitBreaker) health() HealthState {
+	return HealthState{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+		LastFailure:         b.lastFailure,
+		OpenedAt:            b.openedAt,
+		NextProbe:           b.nextProbe,
+	}
+}
+
+// isCircuitFailure reports whether err indicates that the API is unavailable:
+// a server error, a rate limit error or a timeout
+func isCircuitFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true
+	}
+
+	var errorResponse *ErrorResponse
+	if errors.As(err, &errorResponse) {
+		return errorResponse.Response != nil && errorResponse.Response.StatusCode >= 500
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
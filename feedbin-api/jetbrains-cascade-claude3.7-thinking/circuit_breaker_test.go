@@ -0,0 +1,189 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyServer answers every request with status, and blocks probes while
+// hold is set
+type flakyServer struct {
+	mu       sync.Mutex
+	status   int
+	requests int
+	probes   int
+	hold     chan struct{}
+	probing  chan struct{}
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	status, hold, probing := s.status, s.hold, s.probing
+	if r.URL.Path == "/v2/authentication.json" {
+		s.probes++
+	}
+	s.mu.Unlock()
+
+	if r.URL.Path == "/v2/authentication.json" && hold != nil {
+		close(probing)
+		<-hold
+	}
+
+	w.WriteHeader(status)
+	if status == http.StatusOK {
+		w.Write([]byte("[]"))
+	}
+}
+
+func (s *flakyServer) set(status int) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+}
+
+func (s *flakyServer) count() (requests, probes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests, s.probes
+}
+
+func newBreakerTestClient(t *testing.T, server *flakyServer, openTimeout time.Duration) *Client {
+	s := httptest.NewServer(server)
+	t.Cleanup(s.Close)
+
+	c, err := NewClient("user", "pass",
+		WithBaseURL(s.URL+"/v2/"),
+		WithCircuitBreaker(&CircuitBreakerOptions{FailureThreshold: 3, OpenTimeout: openTimeout}))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	t.Cleanup(c.CircuitBreaker().Reset)
+
+	return c
+}
+
+func waitForState(t *testing.T, b *CircuitBreaker, want CircuitState) {
+	deadline := time.Now().Add(2 * time.Second)
+	for b.State() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Circuit is %v, want %v", b.State(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	server := &flakyServer{status: http.StatusInternalServerError}
+	c := newBreakerTestClient(t, server, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Subscriptions.List(nil)
+		if err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Request %d returned %v, want the server error", i+1, err)
+		}
+	}
+
+	if state := c.CircuitBreaker().State(); state != CircuitOpen {
+		t.Fatalf("Circuit is %v after 3 failures, want open", state)
+	}
+
+	_, err := c.Subscriptions.List(nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Request returned %v, want ErrCircuitOpen", err)
+	}
+
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || openErr.RetryAt.IsZero() || openErr.LastError == nil {
+		t.Errorf("Request returned %#v, want a CircuitOpenError with the retry time and last error", err)
+	}
+
+	if requests, _ := server.count(); requests != 3 {
+		t.Errorf("Server got %d requests, want the request to fail fast", requests)
+	}
+
+	health := c.Health()
+	if health.ConsecutiveFailures != 3 || health.NextProbe.IsZero() {
+		t.Errorf("Health = %+v, want 3 failures and a probe time", health)
+	}
+}
+
+func TestCircuitBreakerProbeClosesCircuit(t *testing.T) {
+	server := &flakyServer{status: http.StatusServiceUnavailable}
+	c := newBreakerTestClient(t, server, 10*time.Millisecond)
+
+	var mu sync.Mutex
+	var transitions []CircuitState
+	c.CircuitBreaker().OnStateChange(func(from, to CircuitState, health HealthState) {
+		mu.Lock()
+		transitions = append(transitions, to)
+		mu.Unlock()
+	})
+
+	for i := 0; i < 3; i++ {
+		c.Subscriptions.List(nil)
+	}
+
+	server.set(http.StatusOK)
+	waitForState(t, c.CircuitBreaker(), CircuitClosed)
+
+	if _, err := c.Subscriptions.List(nil); err != nil {
+		t.Errorf("Request after the circuit closed returned %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(transitions) != len(want) {
+		t.Fatalf("Transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("Transitions = %v, want %v", transitions, want)
+			break
+		}
+	}
+}
+
+func TestCircuitBreakerProbeHoldsTrial(t *testing.T) {
+	server := &flakyServer{
+		status:  http.StatusBadGateway,
+		hold:    make(chan struct{}),
+		probing: make(chan struct{}),
+	}
+	c := newBreakerTestClient(t, server, 10*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		c.Subscriptions.List(nil)
+	}
+
+	// While the probe is in flight, other requests cannot take its place
+	<-server.probing
+	if state := c.CircuitBreaker().State(); state != CircuitHalfOpen {
+		t.Fatalf("Circuit is %v during the probe, want half-open", state)
+	}
+
+	before, _ := server.count()
+	if _, err := c.Subscriptions.List(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Request during the probe returned %v, want ErrCircuitOpen", err)
+	}
+	if requests, _ := server.count(); requests != before {
+		t.Errorf("Request during the probe reached the server")
+	}
+
+	// A failed probe reopens the circuit
+	server.mu.Lock()
+	hold := server.hold
+	server.hold = nil
+	server.mu.Unlock()
+	close(hold)
+
+	waitForState(t, c.CircuitBreaker(), CircuitOpen)
+	if _, probes := server.count(); probes == 0 {
+		t.Error("Expected the probe to reach the server")
+	}
+}
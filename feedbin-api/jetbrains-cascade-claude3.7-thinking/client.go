@@ -33,13 +33,18 @@ type Client struct {
 	// User agent for client
 	userAgent string
 
+	// Circuit breaker for API requests, if enabled
+	breaker *CircuitBreaker
+
 	// Services used for communicating with different parts of the Feedbin API
 	Subscriptions  *SubscriptionsService
 	Entries        *EntriesService
 	UnreadEntries  *UnreadEntriesService
 	StarredEntries *StarredEntriesService
+	UpdatedEntries *UpdatedEntriesService
 	Tags           *TagsService
 	Taggings       *TaggingsService
+	RecentlyRead   *RecentlyReadService
 }
 
 // ClientOption is a function that configures a Client
@@ -69,8 +74,10 @@ func NewClient(username, password string, options ...ClientOption) (*Client, err
 	c.Entries = &EntriesService{client: c}
 	c.UnreadEntries = &UnreadEntriesService{client: c}
 	c.StarredEntries = &StarredEntriesService{client: c}
+	c.UpdatedEntries = &UpdatedEntriesService{client: c}
 	c.Tags = &TagsService{client: c}
 	c.Taggings = &TaggingsService{client: c}
+	c.RecentlyRead = &RecentlyReadService{client: c}
 
 	return c, nil
 }
@@ -155,8 +162,25 @@ func (c *Client) newRequest(method, path string, body interface{}) (*http.Reques
 	return req, nil
 }
 
-// do sends an API request and returns the API response
+// do sends an API request and returns the API response. With a circuit
+// breaker, requests fail fast while the circuit is open
 func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
+	if c.breaker == nil {
+		return c.send(req, v)
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(req, v)
+	c.breaker.record(err)
+
+	return resp, err
+}
+
+// send sends an API request and decodes the API response into v
+func (c *Client) send(req *http.Request, v interface{}) (*http.Response, error) {
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
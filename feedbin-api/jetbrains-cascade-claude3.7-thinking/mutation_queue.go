@@ -0,0 +1,614 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxMutationBatch is the maximum number of entry IDs sent in one request
+const maxMutationBatch = 1000
+
+// MutationOp is the kind of a queued mutation
+type MutationOp string
+
+const (
+	MutationMarkAsRead   MutationOp = "mark_as_read"
+	MutationMarkAsUnread MutationOp = "mark_as_unread"
+	MutationStar         MutationOp = "star"
+	MutationUnstar       MutationOp = "unstar"
+	MutationRecentlyRead MutationOp = "recently_read"
+	MutationTag          MutationOp = "tag"
+	MutationUntag        MutationOp = "untag"
+)
+
+// opposite returns the mutation that undoes op, if any
+func (op MutationOp) opposite() (MutationOp, bool) {
+	switch op {
+	case MutationMarkAsRead:
+		return MutationMarkAsUnread, true
+	case MutationMarkAsUnread:
+		return MutationMarkAsRead, true
+	case MutationStar:
+		return MutationUnstar, true
+	case MutationUnstar:
+		return MutationStar, true
+	case MutationTag:
+		return MutationUntag, true
+	case MutationUntag:
+		return MutationTag, true
+	default:
+		return "", false
+	}
+}
+
+// Mutation is a change queued while offline. Entry mutations apply to a
+// single entry; tag mutations add or remove the tag TagName on a feed
+type Mutation struct {
+	Seq      int64      `json:"seq"`
+	Op       MutationOp `json:"op"`
+	EntryID  int64      `json:"entry_id,omitempty"`
+	FeedID   int64      `json:"feed_id,omitempty"`
+	TagName  string     `json:"tag_name,omitempty"`
+	QueuedAt time.Time  `json:"queued_at"`
+}
+
+// target identifies what a mutation changes, so that opposing mutations on
+// the same entry or tagging can be collapsed
+func (m Mutation) target() string {
+	switch m.Op {
+	case MutationMarkAsRead, MutationMarkAsUnread:
+		return fmt.Sprintf("read:%d", m.EntryID)
+	case MutationStar, MutationUnstar:
+		return fmt.Sprintf("starred:%d", m.EntryID)
+	case MutationTag, MutationUntag:
+		return fmt.Sprintf("tagging:%d:%s", m.FeedID, m.TagName)
+	default:
+		return fmt.Sprintf("%s:%d", m.Op, m.EntryID)
+	}
+}
+
+// MutationConflict is a queued mutation that was dropped during replay
+// because the server state no longer matched it
+type MutationConflict struct {
+	Mutation Mutation
+	Reason   string
+}
+
+// ReplayResult reports the outcome of replaying the queue
+type ReplayResult struct {
+	Applied   []Mutation         // Mutations sent to and accepted by the server
+	Conflicts []MutationConflict // Mutations dropped because of the server state
+	Remaining int                // Mutations still queued
+}
+
+// mutationQueueFile is the persisted state of a MutationQueue
+type mutationQueueFile struct {
+	NextSeq   int64      `json:"next_seq"`
+	Mutations []Mutation `json:"mutations"`
+}
+
+// MutationQueue records read, starred, recently read and tagging changes
+// made while offline and replays them in order once the API is reachable.
+// The queue is saved to a file after every change, so it survives process
+// restarts
+type MutationQueue struct {
+	client *Client
+	path   string
+
+	mu        sync.Mutex
+	nextSeq   int64
+	mutations []Mutation
+	inFlight  map[int64]bool
+
+	replayMu sync.Mutex
+	wake     chan struct{}
+}
+
+// NewMutationQueue returns a queue for client persisted at path, loading any
+// mutations saved by a previous process. If the client has a circuit
+// breaker, a running ReplayLoop replays as soon as the circuit closes
+func NewMutationQueue(client *Client, path string) (*MutationQueue, error) {
+	q := &MutationQueue{
+		client:   client,
+		path:     path,
+		nextSeq:  1,
+		inFlight: make(map[int64]bool),
+		wake:     make(chan struct{}, 1),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var f mutationQueueFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("error reading mutation queue: %w", err)
+		}
+		q.mutations = f.Mutations
+		if f.NextSeq > q.nextSeq {
+			q.nextSeq = f.NextSeq
+		}
+	}
+
+	if client.breaker != nil {
+		client.breaker.OnStateChange(func(from, to CircuitState, health HealthState) {
+			if to == CircuitClosed {
+				q.notify()
+			}
+		})
+	}
+
+	return q, nil
+}
+
+// MarkAsRead queues marking entries as read
+func (q *MutationQueue) MarkAsRead(entryIDs []int64) error {
+	return q.enqueueEntries(MutationMarkAsRead, entryIDs)
+}
+
+// MarkAsUnread queues marking entries as unread
+func (q *MutationQueue) MarkAsUnread(entryIDs []int64) error {
+	return q.enqueueEntries(MutationMarkAsUnread, entryIDs)
+}
+
+// Star queues starring entries
+func (q *MutationQueue) Star(entryIDs []int64) error {
+	return q.enqueueEntries(MutationStar, entryIDs)
+}
+
+// Unstar queues removing the star from entries
+func (q *MutationQueue) Unstar(entryIDs []int64) error {
+	return q.enqueueEntries(MutationUnstar, entryIDs)
+}
+
+// MarkAsRecentlyRead queues adding entries to the recently read list
+func (q *MutationQueue) MarkAsRecentlyRead(entryIDs []int64) error {
+	return q.enqueueEntries(MutationRecentlyRead, entryIDs)
+}
+
+// Tag queues adding the tag name to a feed
+func (q *MutationQueue) Tag(feedID int64, name string) error {
+	return q.enqueue([]Mutation{{Op: MutationTag, FeedID: feedID, TagName: name}})
+}
+
+// Untag queues removing the tag name from a feed. The tagging is looked up
+// on the server during replay, so it does not need to exist yet
+func (q *MutationQueue) Untag(feedID int64, name string) error {
+	return q.enqueue([]Mutation{{Op: MutationUntag, FeedID: feedID, TagName: name}})
+}
+
+// Pending returns the queued mutations in order
+func (q *MutationQueue) Pending() []Mutation {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Mutation(nil), q.mutations...)
+}
+
+// Len returns the number of queued mutations
+func (q *MutationQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.mutations)
+}
+
+// enqueueEntries queues op for each entry ID
+func (q *MutationQueue) enqueueEntries(op MutationOp, entryIDs []int64) error {
+	mutations := make([]Mutation, len(entryIDs))
+	for i, id := range entryIDs {
+		mutations[i] = Mutation{Op: op, EntryID: id}
+	}
+	return q.enqueue(mutations)
+}
+
+// enqueue adds mutations to the queue. A mutation cancels a queued opposing
+// mutation on the same target instead of being added, and duplicates of a
+// queued mutation are ignored. Mutations being replayed are never cancelled
+func (q *MutationQueue) enqueue(mutations []Mutation) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := append([]Mutation(nil), q.mutations...)
+	nextSeq := q.nextSeq
+	now := time.Now().UTC()
+
+	for _, m := range mutations {
+		opposite, hasOpposite := m.Op.opposite()
+		target := m.target()
+
+		skip := false
+		for i, queued := range queue {
+			if q.inFlight[queued.Seq] || queued.target() != target {
+				continue
+			}
+			if queued.Op == m.Op {
+				skip = true
+				break
+			}
+			if hasOpposite && queued.Op == opposite {
+				queue = append(queue[:i], queue[i+1:]...)
+				skip = true
+				break
+			}
+		}
+		if skip {
+			continue
+		}
+
+		m.Seq = nextSeq
+		m.QueuedAt = now
+		nextSeq++
+		queue = append(queue, m)
+	}
+
+	if err := q.save(queue, nextSeq); err != nil {
+		return err
+	}
+
+	q.mutations = queue
+	q.nextSeq = nextSeq
+	q.notify()
+
+	return nil
+}
+
+// Replay sends the queued mutations to the server in order, batching
+// consecutive entry mutations of the same kind. The server state is fetched
+// first, and mutations that are already applied or no longer apply are
+// dropped and reported as conflicts. Replay stops at the first failed request
+// and leaves the remaining mutations queued
+func (q *MutationQueue) Replay() (*ReplayResult, error) {
+	q.replayMu.Lock()
+	defer q.replayMu.Unlock()
+
+	q.mu.Lock()
+	pending := append([]Mutation(nil), q.mutations...)
+	for _, m := range pending {
+		q.inFlight[m.Seq] = true
+	}
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		for _, m := range pending {
+			delete(q.inFlight, m.Seq)
+		}
+		q.mu.Unlock()
+	}()
+
+	result := &ReplayResult{}
+	if len(pending) == 0 {
+		return result, nil
+	}
+
+	state, err := q.fetchServerState(pending)
+	if err != nil {
+		result.Remaining = q.Len()
+		return result, err
+	}
+
+	for len(pending) > 0 {
+		batch := nextMutationBatch(pending)
+		pending = pending[len(batch):]
+
+		applied, conflicts, err := q.apply(batch, state)
+		result.Applied = append(result.Applied, applied...)
+		result.Conflicts = append(result.Conflicts, conflicts...)
+
+		done := make([]Mutation, 0, len(applied)+len(conflicts))
+		done = append(done, applied...)
+		for _, c := range conflicts {
+			done = append(done, c.Mutation)
+		}
+		if removeErr := q.remove(done); removeErr != nil && err == nil {
+			err = removeErr
+		}
+
+		if err != nil {
+			result.Remaining = q.Len()
+			return result, err
+		}
+	}
+
+	result.Remaining = q.Len()
+	return result, nil
+}
+
+// ReplayLoop replays the queue every interval while it is not empty, and
+// right away when mutations are queued or the circuit breaker closes, until
+// ctx is done. report, if not nil, is called with the outcome of each replay.
+// interval must be positive
+func (q *MutationQueue) ReplayLoop(ctx context.Context, interval time.Duration, report func(*ReplayResult, error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("replay interval must be positive, got %v", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if q.Len() > 0 {
+			result, err := q.Replay()
+			if report != nil {
+				report(result, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// notify wakes a running ReplayLoop
+func (q *MutationQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// mutationServerState is the server state replayed mutations are checked
+// against
+type mutationServerState struct {
+	unread   map[int64]bool
+	starred  map[int64]bool
+	taggings []*Tagging
+}
+
+// fetchServerState fetches the parts of the server state that the pending
+// mutations depend on
+func (q *MutationQueue) fetchServerState(pending []Mutation) (*mutationServerState, error) {
+	var needUnread, needStarred, needTaggings bool
+	for _, m := range pending {
+		switch m.Op {
+		case MutationMarkAsRead, MutationMarkAsUnread:
+			needUnread = true
+		case MutationStar, MutationUnstar:
+			needStarred = true
+		case MutationTag, MutationUntag:
+			needTaggings = true
+		}
+	}
+
+	state := &mutationServerState{}
+
+	if needUnread {
+		ids, err := q.client.UnreadEntries.List()
+		if err != nil {
+			return nil, err
+		}
+		state.unread = int64Set(ids)
+	}
+
+	if needStarred {
+		ids, err := q.client.StarredEntries.List()
+		if err != nil {
+			return nil, err
+		}
+		state.starred = int64Set(ids)
+	}
+
+	if needTaggings {
+		taggings, err := q.client.Taggings.List()
+		if err != nil {
+			return nil, err
+		}
+		state.taggings = taggings
+	}
+
+	return state, nil
+}
+
+// nextMutationBatch returns the mutations at the start of pending that can be
+// sent in one request
+func nextMutationBatch(pending []Mutation) []Mutation {
+	op := pending[0].Op
+	if op == MutationTag || op == MutationUntag {
+		return pending[:1]
+	}
+
+	n := 1
+	for n < len(pending) && n < maxMutationBatch && pending[n].Op == op {
+		n++
+	}
+	return pending[:n]
+}
+
+// apply sends a batch of mutations of the same kind, skipping the ones that
+// conflict with the server state
+func (q *MutationQueue) apply(batch []Mutation, state *mutationServerState) ([]Mutation, []MutationConflict, error) {
+	var conflicts []MutationConflict
+	conflict := func(m Mutation, reason string) {
+		conflicts = append(conflicts, MutationConflict{Mutation: m, Reason: reason})
+	}
+
+	op := batch[0].Op
+	if op == MutationTag || op == MutationUntag {
+		m := batch[0]
+		tagging := state.findTagging(m.FeedID, m.TagName)
+
+		if op == MutationTag {
+			if tagging != nil {
+				conflict(m, "feed is already tagged")
+				return nil, conflicts, nil
+			}
+			tagging, err := q.client.Taggings.Create(m.FeedID, m.TagName)
+			if err != nil {
+				return nil, nil, err
+			}
+			state.taggings = append(state.taggings, tagging)
+			return []Mutation{m}, nil, nil
+		}
+
+		if tagging == nil {
+			conflict(m, "feed is not tagged")
+			return nil, conflicts, nil
+		}
+		if err := q.client.Taggings.Delete(tagging.ID); err != nil {
+			var notFoundErr *NotFoundError
+			if errors.As(err, &notFoundErr) {
+				conflict(m, "tagging no longer exists")
+				return nil, conflicts, nil
+			}
+			return nil, nil, err
+		}
+		state.removeTagging(tagging.ID)
+		return []Mutation{m}, nil, nil
+	}
+
+	var send []Mutation
+	for _, m := range batch {
+		switch {
+		case op == MutationMarkAsRead && !state.unread[m.EntryID]:
+			conflict(m, "entry is already read")
+		case op == MutationMarkAsUnread && state.unread[m.EntryID]:
+			conflict(m, "entry is already unread")
+		case op == MutationStar && state.starred[m.EntryID]:
+			conflict(m, "entry is already starred")
+		case op == MutationUnstar && !state.starred[m.EntryID]:
+			conflict(m, "entry is not starred")
+		default:
+			send = append(send, m)
+		}
+	}
+
+	if len(send) == 0 {
+		return nil, conflicts, nil
+	}
+
+	ids := make([]int64, len(send))
+	for i, m := range send {
+		ids[i] = m.EntryID
+	}
+
+	var processed []int64
+	var err error
+	switch op {
+	case MutationMarkAsRead:
+		processed, err = q.client.UnreadEntries.MarkAsRead(ids)
+	case MutationMarkAsUnread:
+		processed, err = q.client.UnreadEntries.MarkAsUnread(ids)
+	case MutationStar:
+		processed, err = q.client.StarredEntries.Star(ids)
+	case MutationUnstar:
+		processed, err = q.client.StarredEntries.Unstar(ids)
+	case MutationRecentlyRead:
+		processed, err = q.client.RecentlyRead.Create(ids)
+	default:
+		err = fmt.Errorf("unknown mutation %q", op)
+	}
+	if err != nil {
+		return nil, conflicts, err
+	}
+
+	// The server only returns the IDs it processed; the others no longer
+	// exist or are not accessible
+	done := int64Set(processed)
+	var applied []Mutation
+	for _, m := range send {
+		if !done[m.EntryID] {
+			conflict(m, "entry was not processed by the server")
+			continue
+		}
+		applied = append(applied, m)
+
+		switch op {
+		case MutationMarkAsRead:
+			delete(state.unread, m.EntryID)
+		case MutationMarkAsUnread:
+			state.unread[m.EntryID] = true
+		case MutationStar:
+			state.starred[m.EntryID] = true
+		case MutationUnstar:
+			delete(state.starred, m.EntryID)
+		}
+	}
+
+	return applied, conflicts, nil
+}
+
+// findTagging returns the tagging of the tag name on a feed, or nil
+func (s *mutationServerState) findTagging(feedID int64, name string) *Tagging {
+	for _, t := range s.taggings {
+		if t.FeedID == feedID && t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// removeTagging removes a deleted tagging from the state
+func (s *mutationServerState) removeTagging(id int64) {
+	for i, t := range s.taggings {
+		if t.ID == id {
+			s.taggings = append(s.taggings[:i], s.taggings[i+1:]...)
+			return
+		}
+	}
+}
+
+// remove deletes replayed mutations from the queue and saves it
+func (q *MutationQueue) remove(done []Mutation) error {
+	if len(done) == 0 {
+		return nil
+	}
+
+	seqs := make(map[int64]bool, len(done))
+	for _, m := range done {
+		seqs[m.Seq] = true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := make([]Mutation, 0, len(q.mutations))
+	for _, m := range q.mutations {
+		if !seqs[m.Seq] {
+			queue = append(queue, m)
+		}
+	}
+
+	// The mutations were sent, so drop them even if the queue cannot be saved
+	q.mutations = queue
+	return q.save(queue, q.nextSeq)
+}
+
+// save writes the queue to its file. It must be called with q.mu held
+func (q *MutationQueue) save(mutations []Mutation, nextSeq int64) error {
+	data, err := json.Marshal(&mutationQueueFile{NextSeq: nextSeq, Mutations: mutations})
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(q.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, q.path)
+}
+
+// int64Set returns a set of the given IDs
+func int64Set(ids []int64) map[int64]bool {
+	set := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
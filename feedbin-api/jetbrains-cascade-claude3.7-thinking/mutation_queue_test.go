@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeMutationServer holds the unread, starred and tagging state mutations
+// are replayed against
+type fakeMutationServer struct {
+	mu       sync.Mutex
+	unread   map[int64]bool
+	starred  map[int64]bool
+	taggings []*Tagging
+	nextID   int64
+	failPath string
+	requests []string
+}
+
+func (s *fakeMutationServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	if r.Method != http.MethodGet {
+		s.requests = append(s.requests, r.Method+" "+path)
+	}
+	if path == s.failPath {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var body struct {
+		UnreadEntries  []int64 `json:"unread_entries"`
+		StarredEntries []int64 `json:"starred_entries"`
+		FeedID         int64   `json:"feed_id"`
+		Name           string  `json:"name"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	switch {
+	case path == "unread_entries.json" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(setIDs(s.unread))
+	case path == "starred_entries.json" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(setIDs(s.starred))
+	case path == "taggings.json" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(s.taggings)
+	case path == "unread_entries.json":
+		json.NewEncoder(w).Encode(mutateSet(s.unread, body.UnreadEntries, r.Method == http.MethodPost))
+	case path == "starred_entries.json":
+		json.NewEncoder(w).Encode(mutateSet(s.starred, body.StarredEntries, r.Method == http.MethodPost))
+	case path == "taggings.json":
+		s.nextID++
+		tagging := &Tagging{ID: s.nextID, FeedID: body.FeedID, Name: body.Name}
+		s.taggings = append(s.taggings, tagging)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(tagging)
+	case strings.HasPrefix(path, "taggings/"):
+		for i, t := range s.taggings {
+			if path == fmt.Sprintf("taggings/%d.json", t.ID) {
+				s.taggings = append(s.taggings[:i], s.taggings[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// mutateSet adds or removes ids and returns the ones processed. Like the API,
+// it skips entries the user cannot access, here IDs from 100
+func mutateSet(set map[int64]bool, ids []int64, add bool) []int64 {
+	processed := []int64{}
+	for _, id := range ids {
+		if id >= 100 {
+			continue
+		}
+		if add {
+			set[id] = true
+		} else {
+			delete(set, id)
+		}
+		processed = append(processed, id)
+	}
+	return processed
+}
+
+func setIDs(set map[int64]bool) []int64 {
+	ids := []int64{}
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func newMutationQueueTest(t *testing.T, server *fakeMutationServer) (*MutationQueue, string) {
+	s := httptest.NewServer(server)
+	t.Cleanup(s.Close)
+
+	c, err := NewClient("user", "pass", WithBaseURL(s.URL+"/v2/"))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := NewMutationQueue(c, path)
+	if err != nil {
+		t.Fatalf("NewMutationQueue returned error: %v", err)
+	}
+
+	return q, path
+}
+
+func pendingOps(q *MutationQueue) []string {
+	var ops []string
+	for _, m := range q.Pending() {
+		ops = append(ops, m.target()+" "+string(m.Op))
+	}
+	return ops
+}
+
+func TestMutationQueueCollapsesOpposites(t *testing.T) {
+	q, _ := newMutationQueueTest(t, &fakeMutationServer{})
+
+	q.MarkAsRead([]int64{1, 2})
+	q.MarkAsUnread([]int64{1})
+	q.MarkAsRead([]int64{2})
+	q.Star([]int64{3})
+	q.Unstar([]int64{3})
+	q.Tag(10, "Go")
+	q.Untag(10, "Go")
+	q.Untag(20, "News")
+
+	want := []string{"read:2 mark_as_read", "tagging:20:News untag"}
+	if got := pendingOps(q); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pending = %v, want %v", got, want)
+	}
+}
+
+func TestMutationQueueReloadsFromFile(t *testing.T) {
+	server := &fakeMutationServer{}
+	q, path := newMutationQueueTest(t, server)
+
+	q.MarkAsRead([]int64{1})
+	q.Star([]int64{2})
+
+	reloaded, err := NewMutationQueue(q.client, path)
+	if err != nil {
+		t.Fatalf("NewMutationQueue returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(reloaded.Pending(), q.Pending()) {
+		t.Errorf("Reloaded Pending = %+v, want %+v", reloaded.Pending(), q.Pending())
+	}
+
+	// Sequence numbers continue after the saved ones, and queued mutations
+	// still collapse
+	reloaded.MarkAsUnread([]int64{1})
+	reloaded.MarkAsRecentlyRead([]int64{3})
+
+	pending := reloaded.Pending()
+	if len(pending) != 2 || pending[0].Op != MutationStar || pending[1].Seq != 3 {
+		t.Errorf("Pending after reload = %+v, want the star and a recently read mutation with seq 3", pending)
+	}
+}
+
+func TestMutationQueueReplayConflicts(t *testing.T) {
+	server := &fakeMutationServer{
+		unread:   map[int64]bool{1: true, 2: true, 100: true},
+		starred:  map[int64]bool{5: true},
+		taggings: []*Tagging{{ID: 7, FeedID: 10, Name: "Go"}},
+		nextID:   7,
+	}
+	q, path := newMutationQueueTest(t, server)
+
+	q.MarkAsRead([]int64{1, 3, 100}) // 3 is already read, 100 is not accessible
+	q.Star([]int64{5, 6})            // 5 is already starred
+	q.Tag(10, "Go")                  // already tagged
+	q.Untag(20, "News")              // not tagged
+	q.Tag(30, "News")
+
+	result, err := q.Replay()
+	if err != nil {
+		t.Fatalf("Replay returned error: %v", err)
+	}
+
+	var applied []string
+	for _, m := range result.Applied {
+		applied = append(applied, m.target())
+	}
+	if want := []string{"read:1", "starred:6", "tagging:30:News"}; !reflect.DeepEqual(applied, want) {
+		t.Errorf("Applied = %v, want %v", applied, want)
+	}
+
+	reasons := make(map[string]string)
+	for _, c := range result.Conflicts {
+		reasons[c.Mutation.target()] = c.Reason
+	}
+	want := map[string]string{
+		"read:3":          "entry is already read",
+		"read:100":        "entry was not processed by the server",
+		"starred:5":       "entry is already starred",
+		"tagging:10:Go":   "feed is already tagged",
+		"tagging:20:News": "feed is not tagged",
+	}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("Conflicts = %v, want %v", reasons, want)
+	}
+
+	if result.Remaining != 0 || q.Len() != 0 {
+		t.Errorf("Remaining = %d, want an empty queue", result.Remaining)
+	}
+	if got := setIDs(server.unread); !reflect.DeepEqual(got, []int64{2, 100}) {
+		t.Errorf("Server unread = %v, want [2 100]", got)
+	}
+
+	// The emptied queue was saved
+	reloaded, _ := NewMutationQueue(q.client, path)
+	if reloaded.Len() != 0 {
+		t.Errorf("Reloaded queue has %d mutations, want none", reloaded.Len())
+	}
+}
+
+func TestMutationQueueReplayStopsAtFailure(t *testing.T) {
+	server := &fakeMutationServer{
+		unread:   map[int64]bool{1: true},
+		starred:  map[int64]bool{},
+		failPath: "starred_entries.json",
+	}
+	q, _ := newMutationQueueTest(t, server)
+
+	q.MarkAsRead([]int64{1})
+	q.Star([]int64{2})
+	q.MarkAsRecentlyRead([]int64{3})
+
+	// The starred list itself cannot be fetched, so nothing is sent
+	result, err := q.Replay()
+	if err == nil || result.Remaining != 3 || len(server.requests) != 0 {
+		t.Fatalf("Replay = %+v, %v after %v, want an error and nothing sent", result, err, server.requests)
+	}
+
+	server.mu.Lock()
+	server.failPath = "recently_read_entries.json"
+	server.mu.Unlock()
+
+	result, err = q.Replay()
+	if err == nil {
+		t.Fatal("Expected the failed request to stop the replay")
+	}
+	if len(result.Applied) != 2 || result.Remaining != 1 || q.Pending()[0].Op != MutationRecentlyRead {
+		t.Errorf("Replay = %+v, want 2 applied and the recently read mutation queued", result)
+	}
+}
+
+func TestMutationQueueReplayLoopRejectsNonPositiveInterval(t *testing.T) {
+	q, _ := newMutationQueueTest(t, &fakeMutationServer{})
+
+	if err := q.ReplayLoop(context.Background(), 0, nil); err == nil {
+		t.Error("ReplayLoop with a zero interval returned nil, want an error")
+	}
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"fmt"
+	"time"
 )
 
 // UnreadEntriesService handles communication with the unread entries related
@@ -12,18 +13,24 @@ type UnreadEntriesService struct {
 
 // List returns all unread entry IDs
 func (s *UnreadEntriesService) List() ([]int64, error) {
+	entryIDs, _, err := s.list()
+	return entryIDs, err
+}
+
+// list returns all unread entry IDs and the server time of the response
+func (s *UnreadEntriesService) list() ([]int64, time.Time, error) {
 	req, err := s.client.newRequest("GET", "unread_entries.json", nil)
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
 	}
 
 	var entryIDs []int64
-	_, err = s.client.do(req, &entryIDs)
+	resp, err := s.client.do(req, &entryIDs)
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
 	}
 
-	return entryIDs, nil
+	return entryIDs, serverTime(resp), nil
 }
 
 // MarkAsUnread marks the specified entry IDs as unread
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// UpdatedEntriesService handles communication with the updated entries
+// related methods of the Feedbin API
+type UpdatedEntriesService struct {
+	client *Client
+}
+
+// List returns the IDs of entries modified after they were first published
+func (s *UpdatedEntriesService) List() ([]int64, error) {
+	return s.list("updated_entries.json")
+}
+
+// ListWithSince returns the IDs of entries updated after the specified time
+func (s *UpdatedEntriesService) ListWithSince(since time.Time) ([]int64, error) {
+	return s.list(fmt.Sprintf("updated_entries.json?since=%s", since.Format(time.RFC3339Nano)))
+}
+
+// list returns the entry IDs at path
+func (s *UpdatedEntriesService) list(path string) ([]int64, error) {
+	req, err := s.client.newRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var entryIDs []int64
+	_, err = s.client.do(req, &entryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return entryIDs, nil
+}
+
+// MarkAsRead marks the specified updated entries as read
+func (s *UpdatedEntriesService) MarkAsRead(entryIDs []int64) ([]int64, error) {
+	if len(entryIDs) > 1000 {
+		return nil, fmt.Errorf("maximum of 1000 entry IDs can be marked as read in a single request")
+	}
+
+	type updatedRequest struct {
+		UpdatedEntries []int64 `json:"updated_entries"`
+	}
+
+	req, err := s.client.newRequest("DELETE", "updated_entries.json", &updatedRequest{
+		UpdatedEntries: entryIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var processedIDs []int64
+	_, err = s.client.do(req, &processedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return processedIDs, nil
+}